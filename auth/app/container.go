@@ -0,0 +1,92 @@
+// Package app wires the auth service together: one MongoDB client, the
+// repositories built on it, and the services and controllers that use them.
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rupesh-sengar/golang-collection/auth/config"
+	"github.com/rupesh-sengar/golang-collection/auth/controllers"
+	"github.com/rupesh-sengar/golang-collection/auth/database"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"github.com/rupesh-sengar/golang-collection/auth/infra/mongo_config"
+	"github.com/rupesh-sengar/golang-collection/auth/services"
+	"github.com/rupesh-sengar/golang-collection/auth/token"
+	"github.com/rupesh-sengar/golang-collection/auth/utils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Container struct {
+	Config *config.Config
+	Mongo  *mongo.Client
+	Signer *token.Signer
+
+	Users         domain.UserRepository
+	RefreshTokens domain.RefreshTokenRepository
+	Revocations   domain.RevocationRepository
+
+	Providers *services.ProviderRegistry
+	Auth      *services.AuthService
+	Tokens    *services.TokenService
+
+	Controller *controllers.AuthController
+}
+
+// New connects to MongoDB, ensures indexes once and builds every service
+// from cfg, which must have come from config.Load.
+func New(ctx context.Context, cfg *config.Config) (*Container, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	client, err := database.Connect(ctx, cfg.Mongo.URI)
+	if err != nil {
+		return nil, fmt.Errorf("connect to MongoDB: %w", err)
+	}
+	c := &Container{Config: cfg, Mongo: client}
+	if err := c.init(ctx); err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Container) init(ctx context.Context) error {
+	cfg := c.Config
+	db := c.Mongo.Database(cfg.Mongo.Database)
+
+	users := db.Collection(cfg.Mongo.UsersCollection)
+	if err := mongo_config.EnsureUserIndexes(ctx, users); err != nil {
+		return fmt.Errorf("ensure user indexes: %w", err)
+	}
+	refreshTokens := db.Collection(cfg.Mongo.RefreshTokensCollection)
+	if err := mongo_config.EnsureRefreshTokenIndexes(ctx, refreshTokens); err != nil {
+		return fmt.Errorf("ensure refresh token indexes: %w", err)
+	}
+	revoked := db.Collection(cfg.Mongo.RevokedTokensCollection)
+	if err := mongo_config.EnsureRevocationIndexes(ctx, revoked); err != nil {
+		return fmt.Errorf("ensure revocation indexes: %w", err)
+	}
+
+	c.Users = mongo_config.NewUserRepository(users, cfg.Timeouts.Database)
+	c.RefreshTokens = mongo_config.NewRefreshTokenRepository(refreshTokens, cfg.Timeouts.Database)
+	c.Revocations = mongo_config.NewRevocationRepository(revoked, cfg.Timeouts.Database)
+
+	c.Signer = token.NewSigner(cfg.Keys.Ring, cfg.Tokens)
+
+	providers, err := services.NewProviderRegistry(c.Users, cfg)
+	if err != nil {
+		return fmt.Errorf("configure identity providers: %w", err)
+	}
+	c.Providers = providers
+	c.Auth = services.NewAuthService(c.Users, c.Providers)
+	c.Tokens = services.NewTokenService(c.Users, c.RefreshTokens, c.Revocations, c.Providers, c.Signer, cfg.Introspection)
+	passwords := utils.NewPasswordDecrypter(cfg.Keys.Ring, cfg.Keys.MinEnvelopeVersion, cfg.Keys.EnvelopeWindow)
+	c.Controller = controllers.NewAuthController(c.Auth, c.Tokens, c.Providers, c.Signer, passwords)
+	return nil
+}
+
+func (c *Container) Close(ctx context.Context) error {
+	return c.Mongo.Disconnect(ctx)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rupesh-sengar/golang-collection/auth/utils"
+)
+
+// encrypt prints the enc_password envelope for a password, for trying the
+// login endpoint with curl. The password is read from stdin when it is not
+// given as an argument, which keeps it out of the shell history.
+func encrypt(args []string) error {
+	fs := newFlagSet("encrypt")
+	keyFile := fs.String("key", "", "private key file to encrypt to")
+	ringFile := fs.String("ring", "", "key ring file; encrypts to its primary key")
+	url := fs.String("url", "", "encrypt to the primary key published at this /api/v1/encryption-key URL")
+	kid := fs.String("kid", "", "key ID to put in the envelope (default: the ring or URL's primary key ID)")
+	version := fs.Int("version", -1, "envelope version, 0 (PKCS#1 v1.5) or 1 (RSA-OAEP) (default: the latest the server accepts)")
+	passphraseFile := fs.String("passphrase-file", "", "file holding the passphrase of encrypted keys")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var pub *rsa.PublicKey
+	primaryKid, latest := "", utils.LatestEnvelopeVersion
+	switch {
+	case *keyFile != "":
+		key, err := loadKey(*keyFile, *passphraseFile)
+		if err != nil {
+			return err
+		}
+		pub = &key.PublicKey
+	case *ringFile != "":
+		ring, err := loadRing(*ringFile, *passphraseFile)
+		if err != nil {
+			return err
+		}
+		primary := ring.Primary()
+		pub, primaryKid = &primary.Private.PublicKey, primary.ID
+	case *url != "":
+		var err error
+		if pub, primaryKid, latest, err = fetchEncryptionKey(*url); err != nil {
+			return err
+		}
+	default:
+		return errors.New("one of -key, -ring or -url is required")
+	}
+	if *kid == "" {
+		*kid = primaryKid
+	}
+	if *version < 0 {
+		*version = latest
+	}
+
+	password, err := readPassword(fs.Args())
+	if err != nil {
+		return err
+	}
+	enc, err := utils.EncryptPassword(*version, *kid, pub, password, time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Println(enc)
+	return nil
+}
+
+func readPassword(args []string) (string, error) {
+	switch len(args) {
+	case 0:
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("read password from stdin: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	case 1:
+		return args[0], nil
+	default:
+		return "", errors.New("expected at most one password argument")
+	}
+}
+
+// fetchEncryptionKey reads the primary key and latest envelope version from
+// the service's encryption key endpoint.
+func fetchEncryptionKey(url string) (*rsa.PublicKey, string, int, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", 0, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	var body struct {
+		Kid           string `json:"kid"`
+		LatestVersion int    `json:"latest_version"`
+		Keys          []struct {
+			Kid string `json:"kid"`
+			PEM string `json:"pem"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, "", 0, fmt.Errorf("decode %s: %w", url, err)
+	}
+	for _, k := range body.Keys {
+		if k.Kid != body.Kid {
+			continue
+		}
+		block, _ := pem.Decode([]byte(k.PEM))
+		if block == nil {
+			return nil, "", 0, fmt.Errorf("key %s: invalid PEM", k.Kid)
+		}
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, "", 0, fmt.Errorf("key %s: %w", k.Kid, err)
+		}
+		pub, ok := parsed.(*rsa.PublicKey)
+		if !ok {
+			return nil, "", 0, fmt.Errorf("key %s is not an RSA key", k.Kid)
+		}
+		return pub, body.Kid, body.LatestVersion, nil
+	}
+	return nil, "", 0, fmt.Errorf("%s does not list its primary key %q", url, body.Kid)
+}
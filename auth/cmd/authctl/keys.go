@@ -0,0 +1,304 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rupesh-sengar/golang-collection/auth/config"
+	"github.com/rupesh-sengar/golang-collection/auth/keyring"
+	"github.com/rupesh-sengar/golang-collection/auth/token"
+)
+
+// keysGenerate writes a new private key as PKCS#8 PEM or as a private JWK.
+func keysGenerate(args []string) error {
+	fs := newFlagSet("keys generate")
+	bits := fs.Int("bits", 2048, "RSA key size in bits")
+	kid := fs.String("kid", "", "key ID (default: the key's RFC 7638 thumbprint)")
+	format := fs.String("format", "pem", "output format, pem or jwk")
+	out := fs.String("out", "", "write the private key to this file instead of stdout")
+	public := fs.String("public", "", "also write the public key as PEM to this file")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *format != "pem" && *format != "jwk" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	key, err := generateKey(*bits)
+	if err != nil {
+		return err
+	}
+	if *kid == "" {
+		*kid = keyring.Thumbprint(&key.PublicKey)
+	}
+
+	var encoded []byte
+	if *format == "jwk" {
+		encoded, err = json.MarshalIndent(privateJWK(*kid, key), "", "  ")
+		encoded = append(encoded, '\n')
+	} else {
+		encoded, err = encodePrivateKey(key)
+	}
+	if err != nil {
+		return err
+	}
+	if err := writeOutput(*out, encoded, 0o600); err != nil {
+		return err
+	}
+
+	if *public != "" {
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*public, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Generated key", *kid)
+	return nil
+}
+
+// keysPrint prints a key as base64-encoded PKCS#8 PEM, the form PRIVATE_KEY
+// takes in an environment variable. Keys in other formats are converted, and
+// encrypted keys are printed decrypted.
+func keysPrint(args []string) error {
+	fs := newFlagSet("keys print")
+	keyFile := fs.String("key", "", "private key file, in any format PRIVATE_KEY accepts")
+	passphraseFile := fs.String("passphrase-file", "", "file holding the passphrase of an encrypted key")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	key, err := loadKey(*keyFile, *passphraseFile)
+	if err != nil {
+		return err
+	}
+	encoded, err := encodePrivateKey(key)
+	if err != nil {
+		return err
+	}
+	fmt.Println(base64.StdEncoding.EncodeToString(encoded))
+	return nil
+}
+
+// keysExport prints the public half of a key as a JWK, or of every usable
+// key in a ring as a JWKS.
+func keysExport(args []string) error {
+	fs := newFlagSet("keys export")
+	keyFile := fs.String("key", "", "private key file")
+	ringFile := fs.String("ring", "", "key ring file")
+	kid := fs.String("kid", "", "key ID for -key (default: the key's RFC 7638 thumbprint)")
+	use := fs.String("use", "sig", "key use to publish, sig (RS256) or enc (RSA-OAEP-256)")
+	passphraseFile := fs.String("passphrase-file", "", "file holding the passphrase of encrypted keys")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	describe := token.PublicJWK
+	switch *use {
+	case "sig":
+	case "enc":
+		describe = token.EncryptionJWK
+	default:
+		return fmt.Errorf("unknown key use %q", *use)
+	}
+
+	var out any
+	switch {
+	case *keyFile != "" && *ringFile != "":
+		return errors.New("-key and -ring are mutually exclusive")
+	case *keyFile != "":
+		key, err := loadKey(*keyFile, *passphraseFile)
+		if err != nil {
+			return err
+		}
+		if *kid == "" {
+			*kid = keyring.Thumbprint(&key.PublicKey)
+		}
+		out = describe(*kid, &key.PublicKey)
+	case *ringFile != "":
+		ring, err := loadRing(*ringFile, *passphraseFile)
+		if err != nil {
+			return err
+		}
+		set := token.JWKSet{Keys: []token.JWK{}}
+		for _, k := range ring.Usable() {
+			set.Keys = append(set.Keys, describe(k.ID, &k.Private.PublicKey))
+		}
+		out = set
+	default:
+		return errors.New("-key or -ring is required")
+	}
+
+	encoded, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(encoded))
+	return nil
+}
+
+// keysRotate generates a key, saves it beside the ring file and makes it the
+// ring's primary, retiring the keys in service.
+func keysRotate(args []string) error {
+	fs := newFlagSet("keys rotate")
+	ringFile := fs.String("ring", "", "key ring file, created if it does not exist")
+	bits := fs.Int("bits", 2048, "RSA key size in bits")
+	kid := fs.String("kid", "", "key ID for the new key (default: the key's RFC 7638 thumbprint)")
+	passphraseFile := fs.String("passphrase-file", "", "file holding the passphrase of encrypted keys in the ring")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *ringFile == "" {
+		return errors.New("-ring is required")
+	}
+
+	key, err := generateKey(*bits)
+	if err != nil {
+		return err
+	}
+	if *kid == "" {
+		*kid = keyring.Thumbprint(&key.PublicKey)
+	}
+	if strings.ContainsAny(*kid, `/\`) || strings.HasPrefix(*kid, ".") {
+		return fmt.Errorf("key id %q cannot be used as a file name", *kid)
+	}
+
+	encoded, err := encodePrivateKey(key)
+	if err != nil {
+		return err
+	}
+	name := *kid + ".pem"
+	keyPath := filepath.Join(filepath.Dir(*ringFile), name)
+	f, err := os.OpenFile(keyPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(encoded); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := config.RotateKeyRing(*ringFile, *kid, name, time.Now()); err != nil {
+		return err
+	}
+	// Load the result the way the server will, so a broken ring is caught
+	// here rather than at the next deploy.
+	ring, err := loadRing(*ringFile, *passphraseFile)
+	if err != nil {
+		return fmt.Errorf("rotated ring does not load: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s; %s is now primary of %d usable keys\n", keyPath, ring.Primary().ID, len(ring.Usable()))
+	return nil
+}
+
+func generateKey(bits int) (*rsa.PrivateKey, error) {
+	if bits < 2048 {
+		return nil, fmt.Errorf("key size must be at least 2048 bits, got %d", bits)
+	}
+	return rsa.GenerateKey(rand.Reader, bits)
+}
+
+func encodePrivateKey(key *rsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func loadKey(path, passphraseFile string) (*rsa.PrivateKey, error) {
+	if path == "" {
+		return nil, errors.New("-key is required")
+	}
+	data, err := readInput(path)
+	if err != nil {
+		return nil, err
+	}
+	passphrase, err := readPassphrase(passphraseFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := config.ParsePrivateKey(string(data), passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+func loadRing(path, passphraseFile string) (*keyring.Ring, error) {
+	passphrase, err := readPassphrase(passphraseFile)
+	if err != nil {
+		return nil, err
+	}
+	return config.LoadKeyRing(path, config.Default().Keys.GracePeriod, passphrase)
+}
+
+func readPassphrase(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.TrimRight(string(data), "\r\n")), nil
+}
+
+// readInput reads path, or stdin when path is "-".
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// writeOutput writes data to path with perm, or to stdout when path is empty.
+func writeOutput(path string, data []byte, perm os.FileMode) error {
+	if path == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, perm)
+}
+
+// rsaPrivateJWK is an RSA JWK including its private members (RFC 7518
+// section 6.3), in the form PRIVATE_KEY accepts.
+type rsaPrivateJWK struct {
+	token.JWK
+	D  string `json:"d"`
+	P  string `json:"p"`
+	Q  string `json:"q"`
+	DP string `json:"dp"`
+	DQ string `json:"dq"`
+	QI string `json:"qi"`
+}
+
+func privateJWK(kid string, key *rsa.PrivateKey) rsaPrivateJWK {
+	enc := func(n *big.Int) string { return base64.RawURLEncoding.EncodeToString(n.Bytes()) }
+	return rsaPrivateJWK{
+		JWK: token.PublicJWK(kid, &key.PublicKey),
+		D:   enc(key.D),
+		P:   enc(key.Primes[0]),
+		Q:   enc(key.Primes[1]),
+		DP:  enc(key.Precomputed.Dp),
+		DQ:  enc(key.Precomputed.Dq),
+		QI:  enc(key.Precomputed.Qinv),
+	}
+}
@@ -0,0 +1,119 @@
+// Command authctl is the operator tool for the auth service: it generates,
+// exports and rotates signing keys, manages users through the same service
+// layer as the HTTP API, and builds enc_password envelopes for testing login
+// from a shell.
+//
+//	authctl keys generate [-bits 2048] [-kid id] [-format pem|jwk] [-out file]
+//	authctl keys print -key file
+//	authctl keys export (-key file | -ring file) [-use sig|enc]
+//	authctl keys rotate -ring file [-bits 2048] [-kid id]
+//	authctl encrypt (-key file | -ring file | -url url) [-version 1] [password]
+//	authctl users list [-app name] [-status status] [-deleted] [-json]
+//	authctl users show|approve|reject|suspend|reactivate|delete [-app name] [-json] <id|email>
+//	authctl users restore <id>
+//
+// The users commands read the server's configuration (environment, .env and
+// -config) and record the operator as authctl:<login name>, or -actor.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"keys generate", "generate an RSA private key", keysGenerate},
+	{"keys print", "print a key in the base64 form PRIVATE_KEY expects", keysPrint},
+	{"keys export", "export public keys as a JWK or JWKS", keysExport},
+	{"keys rotate", "add a new primary key to a key ring file", keysRotate},
+	{"encrypt", "build an enc_password envelope for a password", encrypt},
+	{"users list", "list users by application and status", usersList},
+	{"users show", "show one user", usersShow},
+	{"users approve", "approve a pending user", usersApprove},
+	{"users reject", "reject a pending user", usersReject},
+	{"users suspend", "suspend a user", usersSuspend},
+	{"users reactivate", "lift a user's suspension", usersReactivate},
+	{"users delete", "soft-delete a user", usersDelete},
+	{"users restore", "restore a soft-deleted user", usersRestore},
+}
+
+// errUsage reports that usage has already been printed, so main only needs
+// to set the exit status.
+var errUsage = errors.New("usage")
+
+func main() {
+	err := run(os.Args[1:])
+	switch {
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "authctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	for _, cmd := range commands {
+		if rest, ok := matchCommand(cmd.name, args); ok {
+			err := cmd.run(rest)
+			if err != nil && !errors.Is(err, errUsage) {
+				err = fmt.Errorf("%s: %w", cmd.name, err)
+			}
+			return err
+		}
+	}
+	if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "authctl: unknown command %q\n\n", strings.Join(args[:min(len(args), 2)], " "))
+	}
+	usage()
+	return errUsage
+}
+
+// matchCommand reports whether args start with the words of name, and
+// returns the arguments after them.
+func matchCommand(name string, args []string) ([]string, bool) {
+	words := strings.Fields(name)
+	if len(args) < len(words) {
+		return nil, false
+	}
+	for i, w := range words {
+		if args[i] != w {
+			return nil, false
+		}
+	}
+	return args[len(words):], true
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: authctl <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-17s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run authctl <command> -h for the command's flags.")
+}
+
+// newFlagSet returns a flag set for the named command that reports errors
+// instead of exiting, so main decides the exit status.
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet("authctl "+name, flag.ContinueOnError)
+}
+
+// parseFlags parses args into fs. The flag package has already printed the
+// problem and usage when it fails, so the error is replaced with errUsage.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	return nil
+}
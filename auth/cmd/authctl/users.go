@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"os/user"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/rupesh-sengar/golang-collection/auth/app"
+	"github.com/rupesh-sengar/golang-collection/auth/config"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"github.com/rupesh-sengar/golang-collection/auth/services"
+	"github.com/rupesh-sengar/golang-collection/auth/utils/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// adminFlags are the flags every users subcommand takes. The service itself
+// is configured exactly as the server is, from the environment, a .env file
+// and the -config file.
+type adminFlags struct {
+	configFile  string
+	actor       string
+	application string
+	json        bool
+}
+
+func addAdminFlags(fs *flag.FlagSet) *adminFlags {
+	f := &adminFlags{}
+	fs.StringVar(&f.configFile, "config", os.Getenv("CONFIG_FILE"), "path to the service's YAML or TOML config file (CONFIG_FILE)")
+	fs.StringVar(&f.actor, "actor", "", "operator recorded in the audit trail (default: authctl:<login name>)")
+	fs.StringVar(&f.application, "app", "", "only users of this application; needed to pick a user by an email more than one application uses")
+	fs.BoolVar(&f.json, "json", false, "print JSON instead of a table")
+	return f
+}
+
+// withService connects to the service's database and calls fn with the same
+// AuthService the HTTP API uses.
+func (f *adminFlags) withService(fn func(ctx context.Context, auth *services.AuthService) error) error {
+	godotenv.Load()
+	var args []string
+	if f.configFile != "" {
+		args = []string{"-config", f.configFile}
+	}
+	cfg, err := config.Load(args)
+	if err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	container, err := app.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer container.Close(context.Background())
+	return fn(ctx, container.Auth)
+}
+
+// actorID names the operator in audit fields, so changes made from the
+// command line can be told apart from those made through the API.
+func (f *adminFlags) actorID() string {
+	if f.actor != "" {
+		return f.actor
+	}
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	return "authctl:" + name
+}
+
+func usersList(args []string) error {
+	fs := newFlagSet("users list")
+	flags := addAdminFlags(fs)
+	status := fs.String("status", "", "only list users with this status")
+	deleted := fs.Bool("deleted", false, "list soft-deleted users instead")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("unexpected arguments")
+	}
+
+	return flags.withService(func(ctx context.Context, auth *services.AuthService) error {
+		users, err := auth.Users(ctx, domain.UserFilter{
+			Application: flags.application,
+			Status:      domain.UserStatus(*status),
+			Deleted:     *deleted,
+		})
+		if err != nil {
+			return err
+		}
+		if flags.json {
+			if users == nil {
+				users = []*domain.User{}
+			}
+			return printJSON(users)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tEMAIL\tNAME\tAPPLICATION\tSTATUS\tROLES\tUPDATED\tUPDATED BY")
+		for _, u := range users {
+			fmt.Fprintf(w, "%s\t%s\t%s %s\t%s\t%s\t%s\t%s\t%s\n",
+				u.ID.Hex(), u.Email, u.Name.First, u.Name.Last, u.Application, u.Status,
+				joinRoles(u.Roles), u.Audit.UpdatedAt.Format(time.RFC3339), u.Audit.UpdatedBy)
+		}
+		return w.Flush()
+	})
+}
+
+func usersShow(args []string) error {
+	fs := newFlagSet("users show")
+	flags := addAdminFlags(fs)
+	ref, err := parseUserRef(fs, args)
+	if err != nil {
+		return err
+	}
+
+	return flags.withService(func(ctx context.Context, auth *services.AuthService) error {
+		u, err := auth.User(ctx, ref, flags.application)
+		if err != nil {
+			return err
+		}
+		if flags.json {
+			return printJSON(u)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, row := range [][2]string{
+			{"ID", u.ID.Hex()},
+			{"Email", string(u.Email)},
+			{"Name", u.Name.First + " " + u.Name.Last},
+			{"Application", u.Application},
+			{"Status", string(u.Status)},
+			{"Roles", joinRoles(u.Roles)},
+			{"Approved by", u.ApprovedBy},
+			{"Created", u.Audit.CreatedAt.Format(time.RFC3339) + " by " + u.Audit.CreatedBy},
+			{"Updated", u.Audit.UpdatedAt.Format(time.RFC3339) + " by " + u.Audit.UpdatedBy},
+			{"Version", fmt.Sprint(u.Audit.Version)},
+		} {
+			fmt.Fprintf(w, "%s:\t%s\n", row[0], row[1])
+		}
+		return w.Flush()
+	})
+}
+
+// usersApprove approves users of any application: operators are not
+// scoped to one the way admins are.
+func usersApprove(args []string) error {
+	return userAction("users approve", "Approved", args, func(auth *services.AuthService, ctx context.Context, u *domain.User, actor string) error {
+		return auth.Approve(ctx, types.AuthApprovalRequest{Id: u.ID}, actor, "")
+	})
+}
+
+func usersReject(args []string) error {
+	return userAction("users reject", "Rejected", args, (*services.AuthService).Reject)
+}
+
+func usersSuspend(args []string) error {
+	return userAction("users suspend", "Suspended", args, (*services.AuthService).Suspend)
+}
+
+func usersReactivate(args []string) error {
+	return userAction("users reactivate", "Reactivated", args, (*services.AuthService).Reactivate)
+}
+
+func usersDelete(args []string) error {
+	return userAction("users delete", "Deleted", args, (*services.AuthService).Delete)
+}
+
+// usersRestore takes an ID rather than an email, since deleted users cannot
+// be looked up; users list -deleted shows the IDs.
+func usersRestore(args []string) error {
+	fs := newFlagSet("users restore")
+	flags := addAdminFlags(fs)
+	ref, err := parseUserRef(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := primitive.ObjectIDFromHex(ref)
+	if err != nil {
+		return fmt.Errorf("%q is not a user ID", ref)
+	}
+
+	return flags.withService(func(ctx context.Context, auth *services.AuthService) error {
+		if err := auth.Restore(ctx, id, flags.actorID()); err != nil {
+			return err
+		}
+		fmt.Println("Restored user", id.Hex())
+		return nil
+	})
+}
+
+// userAction runs a change against the one user named by its ID or email.
+func userAction(name, done string, args []string, change func(auth *services.AuthService, ctx context.Context, u *domain.User, actor string) error) error {
+	fs := newFlagSet(name)
+	flags := addAdminFlags(fs)
+	ref, err := parseUserRef(fs, args)
+	if err != nil {
+		return err
+	}
+
+	return flags.withService(func(ctx context.Context, auth *services.AuthService) error {
+		u, err := auth.User(ctx, ref, flags.application)
+		if err != nil {
+			return err
+		}
+		if err := change(auth, ctx, u, flags.actorID()); err != nil {
+			return err
+		}
+		fmt.Printf("%s user %s (%s)\n", done, u.ID.Hex(), u.Email)
+		return nil
+	})
+}
+
+// parseUserRef parses the flags and returns the single user ID or email
+// that must follow them.
+func parseUserRef(fs *flag.FlagSet, args []string) (string, error) {
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags] <id|email>\n", fs.Name())
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return "", err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return "", errUsage
+	}
+	return fs.Arg(0), nil
+}
+
+func joinRoles(roles []domain.UserRole) string {
+	names := make([]string, len(roles))
+	for i, r := range roles {
+		names[i] = string(r)
+	}
+	return strings.Join(names, ",")
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
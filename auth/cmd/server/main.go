@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"time"
@@ -8,6 +9,7 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/rupesh-sengar/golang-collection/auth/app"
 	"github.com/rupesh-sengar/golang-collection/auth/config"
 	"github.com/rupesh-sengar/golang-collection/auth/routes"
 )
@@ -17,11 +19,17 @@ func main() {
 		log.Println("No .env file found. Using system environment variables.")
 	}
 
-	err := config.LoadRSAKeys()
+	cfg, err := config.Load(os.Args[1:])
 	if err != nil {
-		log.Fatalf("Failed to load RSA keys: %v", err)
+		log.Fatalf("Invalid configuration:\n%v", err)
 	}
 
+	container, err := app.New(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialise application: %v", err)
+	}
+	defer container.Close(context.Background())
+
 	r := gin.Default()
 	config := cors.Config{
 		AllowOrigins:     []string{"*"},
@@ -32,12 +40,9 @@ func main() {
 		MaxAge:           12 * time.Hour,
 	}
 	r.Use(cors.New(config))
-	routes.RegisterRoutes(r)
+	routes.RegisterRoutes(r, container)
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	port := cfg.Server.Port
 	log.Printf("Server is running on port %s", port)
 	r.Run(":" + port)
 }
@@ -1,46 +1,256 @@
+// Package config loads the auth service's settings once at startup. Values
+// come from built-in defaults, then an optional YAML or TOML file, then the
+// secrets directory, then the environment (including <env>_FILE for
+// secrets), then command-line flags, each overriding the one before. The
+// resulting Config is validated as a whole and passed to the components that
+// need it; nothing reads the environment after Load returns.
 package config
 
 import (
-	"crypto/rsa"
-	"crypto/x509"
-	"encoding/base64"
-	"encoding/pem"
-	"fmt"
 	"errors"
+	"flag"
+	"fmt"
 	"os"
+	"strconv"
+	"time"
+
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"github.com/rupesh-sengar/golang-collection/auth/keyring"
 )
 
-var PrivateKey *rsa.PrivateKey
+type Config struct {
+	Server        ServerConfig
+	Mongo         MongoConfig
+	Keys          KeyConfig
+	Tokens        TokenSettings
+	Timeouts      TimeoutSettings
+	Password      domain.PasswordHasher
+	Identity      IdentityConfig
+	Auth0         Auth0Config
+	Introspection IntrospectionConfig
+}
+
+type ServerConfig struct {
+	Port string
+}
+
+type MongoConfig struct {
+	URI                     string
+	Database                string
+	UsersCollection         string
+	RefreshTokensCollection string
+	RevokedTokensCollection string
+}
+
+type KeyConfig struct {
+	// PrivateKey is the signing and decryption key, either as-is (from a
+	// secret file) or base64-encoded (from an environment variable). It is
+	// ignored when RingFile is set. See ParsePrivateKey for the formats.
+	PrivateKey string
+	// Passphrase decrypts encrypted PKCS#8 keys, here or in the ring.
+	Passphrase string
+	// RingFile names a JSON key ring listing several keys for rotation.
+	RingFile string
+	// GracePeriod is how long a retired ring key keeps decrypting and
+	// verifying after its retired_at time.
+	GracePeriod time.Duration
+	// MinEnvelopeVersion is the oldest enc_password envelope version
+	// accepted. Raise it to 1 once clients use RSA-OAEP to stop accepting
+	// PKCS#1 v1.5.
+	MinEnvelopeVersion int
+	// EnvelopeWindow is how far an enc_password timestamp may be from the
+	// server clock, in either direction. Envelopes are also remembered for
+	// this long so each is accepted only once.
+	EnvelopeWindow time.Duration
+	// Ring is built by Load from RingFile or PrivateKey.
+	Ring *keyring.Ring
+}
+
+type IdentityConfig struct {
+	// Provider is the default identity provider, auth0 or local. Load
+	// picks auth0 when it is unset and Auth0.Domain is, and local otherwise.
+	Provider string
+	// Overrides maps an application to the provider it uses instead.
+	Overrides map[string]string
+}
+
+type Auth0Config struct {
+	Domain       string
+	Audience     string
+	ClientID     string
+	ClientSecret string
+}
+
+type IntrospectionConfig struct {
+	// Clients maps client IDs to the secrets resource servers use to
+	// authenticate against the introspection endpoint.
+	Clients map[string]string
+}
+
+// DefaultSecretsDir is where Docker and Kubernetes mount secrets by default.
+const DefaultSecretsDir = "/run/secrets"
 
-func LoadRSAKeys() error {
-	encodedPrivateKey := os.Getenv("PRIVATE_KEY")
-	if encodedPrivateKey == "" {
-		return errors.New("PRIVATE_KEY environment variable not set")
+// Default returns the settings used when nothing overrides them.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{Port: "8080"},
+		Mongo: MongoConfig{
+			Database:                "User-Management",
+			UsersCollection:         "Users",
+			RefreshTokensCollection: "RefreshTokens",
+			RevokedTokensCollection: "RevokedTokens",
+		},
+		Keys: KeyConfig{
+			GracePeriod:    30 * 24 * time.Hour,
+			EnvelopeWindow: 5 * time.Minute,
+		},
+		Tokens: TokenSettings{
+			Issuer:          "urn:golang-collection:auth",
+			Audience:        "golang-collection",
+			AccessTokenTTL:  15 * time.Minute,
+			IDTokenTTL:      time.Hour,
+			RefreshTokenTTL: 30 * 24 * time.Hour,
+		},
+		Timeouts: TimeoutSettings{
+			Request:  15 * time.Second,
+			Database: 5 * time.Second,
+			Auth0:    10 * time.Second,
+		},
+		Password:      domain.DefaultPasswordHasher(),
+		Identity:      IdentityConfig{Overrides: map[string]string{}},
+		Introspection: IntrospectionConfig{Clients: map[string]string{}},
 	}
+}
 
-	privateKeyBytes, err := base64.StdEncoding.DecodeString(encodedPrivateKey)
-	if err != nil {
-		return fmt.Errorf("failed to base64 decode private key: %w", err)
+// Load builds the Config from defaults, the file named by -config or
+// CONFIG_FILE, the environment and args (usually os.Args[1:]). Every problem
+// found is reported together in one joined error.
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+	settings := cfg.settings()
+
+	fs := flag.NewFlagSet("auth", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file (CONFIG_FILE)")
+	secretsDir := fs.String("secrets-dir", envOr("SECRETS_DIR", DefaultSecretsDir), "directory of mounted secret files (SECRETS_DIR)")
+	var flagValues []sourcedValue
+	for _, s := range settings {
+		fs.Func(s.key, s.usage+" ("+s.env+")", func(raw string) error {
+			flagValues = append(flagValues, sourcedValue{setting: s, source: "flag -" + s.key, raw: raw})
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
 	}
 
-	block, _ := pem.Decode(privateKeyBytes)
-	if block == nil {
-		return errors.New("failed to parse PEM block containing the private key")
+	var errs []error
+	if *configFile != "" {
+		values, err := readFile(*configFile, settings)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, apply(values)...)
 	}
-	if block.Type != "PRIVATE KEY" {
-		return fmt.Errorf("unexpected key type: %s", block.Type)
+	values, loadErrs := secretDirValues(*secretsDir, settings)
+	errs = append(errs, loadErrs...)
+	errs = append(errs, apply(values)...)
+	values, loadErrs = envValues(settings)
+	errs = append(errs, loadErrs...)
+	errs = append(errs, apply(values)...)
+	errs = append(errs, apply(flagValues)...)
+
+	if cfg.Identity.Provider == "" {
+		cfg.Identity.Provider = "local"
+		if cfg.Auth0.Domain != "" {
+			cfg.Identity.Provider = "auth0"
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	switch {
+	case cfg.Keys.RingFile != "":
+		ring, err := LoadKeyRing(cfg.Keys.RingFile, cfg.Keys.GracePeriod, []byte(cfg.Keys.Passphrase))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("KEY_RING_FILE: %w", err))
+		}
+		cfg.Keys.Ring = ring
+	case cfg.Keys.PrivateKey != "":
+		key, err := ParsePrivateKey(cfg.Keys.PrivateKey, []byte(cfg.Keys.Passphrase))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("PRIVATE_KEY: %w", err))
+		} else {
+			cfg.Keys.Ring = keyring.Single(key)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks settings that are individually well-formed but missing or
+// inconsistent with each other.
+func (c *Config) Validate() error {
+	var errs []error
+	required := func(value, env string) {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", env))
+		}
 	}
 
-	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
-	if err != nil {
-		return fmt.Errorf("failed to parse PKCS8 private key: %w", err)
+	if _, err := strconv.ParseUint(c.Server.Port, 10, 16); err != nil {
+		errs = append(errs, fmt.Errorf("PORT must be a port number, got %q", c.Server.Port))
+	}
+	required(c.Mongo.URI, "MONGODB_URI")
+	required(c.Mongo.Database, "MONGODB_DATABASE")
+	required(c.Mongo.UsersCollection, "MONGODB_USERS_COLLECTION")
+	required(c.Mongo.RefreshTokensCollection, "MONGODB_REFRESH_TOKENS_COLLECTION")
+	required(c.Mongo.RevokedTokensCollection, "MONGODB_REVOKED_TOKENS_COLLECTION")
+	if c.Keys.PrivateKey == "" && c.Keys.RingFile == "" {
+		errs = append(errs, errors.New("PRIVATE_KEY or KEY_RING_FILE is required"))
 	}
+	if c.Keys.MinEnvelopeVersion < 0 || c.Keys.MinEnvelopeVersion > 1 {
+		errs = append(errs, fmt.Errorf("ENC_PASSWORD_MIN_VERSION must be 0 or 1, got %d", c.Keys.MinEnvelopeVersion))
+	}
+	required(c.Tokens.Issuer, "TOKEN_ISSUER")
+	required(c.Tokens.Audience, "TOKEN_AUDIENCE")
 
-	rsaKey, ok := key.(*rsa.PrivateKey)
-	if !ok {
-		return errors.New("key is not an RSA private key")
+	if err := c.Password.Validate(); err != nil {
+		errs = append(errs, err)
 	}
 
-	PrivateKey = rsaKey
-	return nil
+	if err := validProvider(c.Identity.Provider); err != nil {
+		errs = append(errs, fmt.Errorf("IDENTITY_PROVIDER: %w", err))
+	}
+	usesAuth0 := c.Identity.Provider == "auth0"
+	for app, name := range c.Identity.Overrides {
+		if err := validProvider(name); err != nil {
+			errs = append(errs, fmt.Errorf("IDENTITY_PROVIDER_OVERRIDES[%s]: %w", app, err))
+		}
+		usesAuth0 = usesAuth0 || name == "auth0"
+	}
+	if usesAuth0 {
+		required(c.Auth0.Domain, "AUTH0_DOMAIN")
+		required(c.Auth0.ClientID, "AUTH0_CLIENT_ID")
+		required(c.Auth0.ClientSecret, "AUTH0_CLIENT_SECRET")
+	}
+
+	return errors.Join(errs...)
+}
+
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func validProvider(name string) error {
+	switch name {
+	case "auth0", "local":
+		return nil
+	default:
+		return fmt.Errorf("unknown identity provider %q", name)
+	}
 }
@@ -0,0 +1,223 @@
+package config
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// isolateEnv clears every variable Load reads, so the tests see only what
+// they set, and points the secrets directory at an empty one.
+func isolateEnv(t *testing.T) {
+	t.Helper()
+	for _, s := range Default().settings() {
+		t.Setenv(s.env, "")
+		t.Setenv(s.env+"_FILE", "")
+	}
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("SECRETS_DIR", t.TempDir())
+}
+
+// minimalEnv sets the settings Validate requires.
+func minimalEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("MONGODB_URI", "mongodb://env")
+	t.Setenv("PRIVATE_KEY", pemOf("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey(t))))
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	// Each source overrides the ones before it: file < secrets dir <
+	// environment or <env>_FILE < flags.
+	tests := []struct {
+		name    string
+		file    bool
+		dir     bool
+		env     bool
+		envFile bool
+		flag    bool
+		want    string
+	}{
+		{name: "config file", file: true, want: "mongodb://file"},
+		{name: "secrets dir over file", file: true, dir: true, want: "mongodb://dir"},
+		{name: "environment over secrets dir", file: true, dir: true, env: true, want: "mongodb://env"},
+		{name: "_FILE over secrets dir", file: true, dir: true, envFile: true, want: "mongodb://env-file"},
+		{name: "flag over environment", file: true, dir: true, env: true, flag: true, want: "mongodb://flag"},
+		{name: "flag over _FILE", dir: true, envFile: true, flag: true, want: "mongodb://flag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateEnv(t)
+			minimalEnv(t)
+			t.Setenv("MONGODB_URI", "")
+			tmp := t.TempDir()
+			var args []string
+			if tt.file {
+				t.Setenv("CONFIG_FILE", writeFile(t, tmp, "auth.yaml", "mongo:\n  uri: mongodb://file\n"))
+			}
+			if tt.dir {
+				dir := filepath.Join(tmp, "secrets")
+				if err := os.Mkdir(dir, 0o700); err != nil {
+					t.Fatal(err)
+				}
+				writeFile(t, dir, "mongodb_uri", "mongodb://dir\n")
+				args = append(args, "-secrets-dir", dir)
+			}
+			if tt.env {
+				t.Setenv("MONGODB_URI", "mongodb://env")
+			}
+			if tt.envFile {
+				t.Setenv("MONGODB_URI_FILE", writeFile(t, tmp, "uri", "mongodb://env-file\n"))
+			}
+			if tt.flag {
+				args = append(args, "-mongo.uri", "mongodb://flag")
+			}
+
+			cfg, err := Load(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Mongo.URI != tt.want {
+				t.Errorf("MONGODB_URI = %q, want %q", cfg.Mongo.URI, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		check   func(t *testing.T, cfg *Config)
+		wantErr []string
+	}{
+		{
+			name:    "YAML",
+			file:    "auth.yaml",
+			content: "server:\n  port: \"9090\"\ntokens:\n  access_ttl: 5m\nidentity:\n  overrides:\n    crm: local\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Server.Port != "9090" || cfg.Tokens.AccessTokenTTL.String() != "5m0s" || cfg.Identity.Overrides["crm"] != "local" {
+					t.Errorf("config = %+v", cfg)
+				}
+			},
+		},
+		{
+			name:    "TOML",
+			file:    "auth.toml",
+			content: "[server]\nport = \"9090\"\n[password]\nbcrypt_cost = 12\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Server.Port != "9090" || cfg.Password.BcryptCost != 12 {
+					t.Errorf("config = %+v", cfg)
+				}
+			},
+		},
+		{
+			name:    "unknown keys",
+			file:    "auth.yaml",
+			content: "mongo:\n  databse: users\nservre:\n  port: \"9090\"\n",
+			wantErr: []string{"unknown keys mongo.databse, servre.port"},
+		},
+		{
+			name:    "unsupported extension",
+			file:    "auth.json",
+			content: "{}",
+			wantErr: []string{"unsupported extension"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateEnv(t)
+			minimalEnv(t)
+			path := writeFile(t, t.TempDir(), tt.file, tt.content)
+
+			cfg, err := Load([]string{"-config", path})
+			if len(tt.wantErr) > 0 {
+				if err == nil {
+					t.Fatal("Load succeeded")
+				}
+				for _, want := range tt.wantErr {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("err = %v, want it to mention %q", err, want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		wantErr []string
+	}{
+		{
+			name:    "value and _FILE both set",
+			env:     map[string]string{"MONGODB_URI_FILE": "/dev/null"},
+			wantErr: []string{"MONGODB_URI and MONGODB_URI_FILE are both set"},
+		},
+		{
+			name:    "missing _FILE",
+			env:     map[string]string{"MONGODB_URI": "", "MONGODB_URI_FILE": "/nonexistent/uri"},
+			wantErr: []string{"MONGODB_URI_FILE:"},
+		},
+		{
+			name: "every problem is reported",
+			env: map[string]string{
+				"MONGODB_URI":          "",
+				"ACCESS_TOKEN_TTL":     "soon",
+				"PASSWORD_BCRYPT_COST": "many",
+				"IDENTITY_PROVIDER":    "ldap",
+			},
+			args: []string{"-server.port", "http"},
+			wantErr: []string{
+				`ACCESS_TOKEN_TTL: invalid duration "soon"`,
+				`PASSWORD_BCRYPT_COST: invalid positive integer "many"`,
+				`PORT must be a port number, got "http"`,
+				"MONGODB_URI",
+				"IDENTITY_PROVIDER",
+			},
+		},
+		{
+			name:    "invalid argon2id parameters",
+			env:     map[string]string{"PASSWORD_ARGON2_ITERATIONS": "100"},
+			wantErr: []string{"argon2id iterations must be between 1 and 64"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateEnv(t)
+			minimalEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			_, err := Load(tt.args)
+			if err == nil {
+				t.Fatal("Load succeeded")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("err = %v\nwant it to mention %q", err, want)
+				}
+			}
+		})
+	}
+}
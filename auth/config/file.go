@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// readFile loads a YAML (.yaml, .yml) or TOML (.toml) config file. Keys are
+// nested tables matching the setting keys, so "mongo.uri" is
+//
+//	mongo:
+//	  uri: mongodb://localhost:27017
+//
+// in YAML. Unknown keys are reported rather than ignored, to catch typos.
+func readFile(path string, settings []setting) ([]sourcedValue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	var tree map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported extension, want .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	byKey := make(map[string]setting, len(settings))
+	for _, s := range settings {
+		byKey[s.key] = s
+	}
+
+	var (
+		values []sourcedValue
+		errs   []string
+	)
+	var walk func(prefix string, node map[string]any)
+	walk = func(prefix string, node map[string]any) {
+		for name, v := range node {
+			key := prefix + name
+			s, known := byKey[key]
+			table, isTable := v.(map[string]any)
+			switch {
+			case known && s.pairSep != "" && isTable:
+				values = append(values, sourcedValue{setting: s, source: path + ": " + key, raw: joinPairs(table, s.pairSep)})
+			case known && !isTable:
+				values = append(values, sourcedValue{setting: s, source: path + ": " + key, raw: fmt.Sprint(v)})
+			case isTable:
+				walk(key+".", table)
+			default:
+				errs = append(errs, key)
+			}
+		}
+	}
+	walk("", tree)
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return nil, fmt.Errorf("config file %s: unknown keys %s", path, strings.Join(errs, ", "))
+	}
+	return values, nil
+}
+
+func joinPairs(table map[string]any, sep string) string {
+	pairs := make([]string, 0, len(table))
+	for k, v := range table {
+		pairs = append(pairs, k+sep+fmt.Sprint(v))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
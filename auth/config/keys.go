@@ -0,0 +1,277 @@
+package config
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rupesh-sengar/golang-collection/auth/keyring"
+	"github.com/youmark/pkcs8"
+)
+
+// ParsePrivateKey reads an RSA private key in any of the formats operators
+// hand us, detected from the input itself:
+//
+//   - PEM "PRIVATE KEY" (PKCS#8), the format PRIVATE_KEY has always used
+//   - PEM "ENCRYPTED PRIVATE KEY" (PKCS#8 encrypted with passphrase)
+//   - PEM "RSA PRIVATE KEY" (PKCS#1)
+//   - a JWK, or a JWKS holding exactly one private RSA key
+//
+// Any of these may also be base64-encoded, as environment variables usually
+// are. Errors name the format that was detected and why it was rejected.
+func ParsePrivateKey(encodedPrivateKey string, passphrase []byte) (*rsa.PrivateKey, error) {
+	input := strings.TrimSpace(encodedPrivateKey)
+	if !strings.HasPrefix(input, "-----BEGIN") && !strings.HasPrefix(input, "{") {
+		decoded, err := base64.StdEncoding.DecodeString(input)
+		if err != nil {
+			return nil, fmt.Errorf("key is neither PEM, JWK JSON nor base64 of either: %w", err)
+		}
+		input = strings.TrimSpace(string(decoded))
+	}
+
+	switch {
+	case strings.HasPrefix(input, "-----BEGIN"):
+		return parsePEMKey([]byte(input), passphrase)
+	case strings.HasPrefix(input, "{"):
+		return parseJWKKey([]byte(input))
+	default:
+		return nil, errors.New("key decoded from base64 is neither PEM nor JWK JSON")
+	}
+}
+
+func parsePEMKey(data, passphrase []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("detected PEM: no valid PEM block found")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("detected PKCS#8 PEM: %w", err)
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("detected PKCS#8 PEM: holds a %T, not an RSA key", key)
+		}
+		return rsaKey, nil
+
+	case "ENCRYPTED PRIVATE KEY":
+		if len(passphrase) == 0 {
+			return nil, errors.New("detected encrypted PKCS#8 PEM: no passphrase configured (set PRIVATE_KEY_PASSPHRASE_FILE)")
+		}
+		key, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("detected encrypted PKCS#8 PEM: %w (wrong passphrase?)", err)
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("detected encrypted PKCS#8 PEM: holds a %T, not an RSA key", key)
+		}
+		return rsaKey, nil
+
+	case "RSA PRIVATE KEY":
+		if _, ok := block.Headers["DEK-Info"]; ok {
+			return nil, errors.New("detected PKCS#1 PEM with legacy OpenSSL encryption: unsupported, convert it with `openssl pkcs8 -topk8`")
+		}
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("detected PKCS#1 PEM: %w", err)
+		}
+		return key, nil
+
+	default:
+		return nil, fmt.Errorf("detected PEM block %q: not an RSA private key", block.Type)
+	}
+}
+
+// jwk holds the RSA members of a JSON Web Key (RFC 7518 section 6.3).
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	D   string `json:"d"`
+	P   string `json:"p"`
+	Q   string `json:"q"`
+}
+
+func parseJWKKey(data []byte) (*rsa.PrivateKey, error) {
+	var doc struct {
+		jwk
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("detected JSON: not a valid JWK or JWKS: %w", err)
+	}
+	if doc.Keys == nil {
+		key, err := doc.jwk.rsaPrivateKey()
+		if err != nil {
+			return nil, fmt.Errorf("detected JWK: %w", err)
+		}
+		return key, nil
+	}
+
+	var found []*rsa.PrivateKey
+	for _, k := range doc.Keys {
+		if key, err := k.rsaPrivateKey(); err == nil {
+			found = append(found, key)
+		}
+	}
+	switch len(found) {
+	case 1:
+		return found[0], nil
+	case 0:
+		return nil, fmt.Errorf("detected JWKS with %d keys: none is a private RSA key", len(doc.Keys))
+	default:
+		return nil, fmt.Errorf("detected JWKS: holds %d private RSA keys, use KEY_RING_FILE for more than one", len(found))
+	}
+}
+
+func (k jwk) rsaPrivateKey() (*rsa.PrivateKey, error) {
+	if k.Kty != "RSA" {
+		return nil, fmt.Errorf("kty %q is not RSA", k.Kty)
+	}
+	if k.D == "" {
+		return nil, errors.New("public key only, no \"d\" member")
+	}
+	if k.P == "" || k.Q == "" {
+		return nil, errors.New("missing the \"p\" and \"q\" primes")
+	}
+
+	var n, e, d, p, q big.Int
+	for name, dst := range map[string]struct {
+		raw string
+		v   *big.Int
+	}{"n": {k.N, &n}, "e": {k.E, &e}, "d": {k.D, &d}, "p": {k.P, &p}, "q": {k.Q, &q}} {
+		b, err := base64.RawURLEncoding.DecodeString(dst.raw)
+		if err != nil || len(b) == 0 {
+			return nil, fmt.Errorf("invalid %q member", name)
+		}
+		dst.v.SetBytes(b)
+	}
+	if !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, errors.New("public exponent out of range")
+	}
+
+	key := &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{N: &n, E: int(e.Int64())},
+		D:         &d,
+		Primes:    []*big.Int{&p, &q},
+	}
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+	key.Precompute()
+	return key, nil
+}
+
+// keyRingFile is the KEY_RING_FILE format. Keys are listed newest first; the
+// first one not retired is primary, and at least one key must have no
+// retired_at.
+//
+//	{"keys": [
+//	  {"kid": "2026-10", "private_key_file": "/run/secrets/rsa-2026-10.pem"},
+//	  {"kid": "2026-04", "private_key_file": "/run/secrets/rsa-2026-04.pem",
+//	   "retired_at": "2026-10-01T00:00:00Z"}
+//	]}
+type keyRingFile struct {
+	Keys []keyRingEntry `json:"keys"`
+}
+
+type keyRingEntry struct {
+	Kid            string     `json:"kid,omitempty"`
+	PrivateKey     string     `json:"private_key,omitempty"`
+	PrivateKeyFile string     `json:"private_key_file,omitempty"`
+	RetiredAt      *time.Time `json:"retired_at,omitempty"`
+}
+
+// LoadKeyRing reads a key ring file. Relative private_key_file paths are
+// resolved against the ring file's directory.
+func LoadKeyRing(path string, grace time.Duration, passphrase []byte) (*keyring.Ring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file keyRingFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	keys := make([]*keyring.Key, 0, len(file.Keys))
+	for i, entry := range file.Keys {
+		encoded := entry.PrivateKey
+		if entry.PrivateKeyFile != "" {
+			keyPath := entry.PrivateKeyFile
+			if !filepath.IsAbs(keyPath) {
+				keyPath = filepath.Join(filepath.Dir(path), keyPath)
+			}
+			if encoded, err = readSecret(keyPath); err != nil {
+				return nil, fmt.Errorf("key %d (%s): %w", i, entry.Kid, err)
+			}
+		}
+		key, err := ParsePrivateKey(encoded, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("key %d (%s): %w", i, entry.Kid, err)
+		}
+		kid := entry.Kid
+		if kid == "" {
+			kid = keyring.Thumbprint(&key.PublicKey)
+		}
+		keys = append(keys, &keyring.Key{ID: kid, Private: key, RetiredAt: entry.RetiredAt})
+	}
+	return keyring.New(keys, grace)
+}
+
+// RotateKeyRing adds the key in keyFile to the ring file at path as its new
+// primary, identified by kid, and retires every key that was not already
+// retired as of now. The ring file is created if it does not exist. Retired
+// keys stay listed so they keep verifying for the grace period; removing them
+// afterwards is left to the operator.
+func RotateKeyRing(path, kid, keyFile string, now time.Time) error {
+	var file keyRingFile
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(data, &file); err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+
+	retiredAt := now.UTC()
+	for i, entry := range file.Keys {
+		if entry.Kid == kid {
+			return fmt.Errorf("key id %q is already in %s", kid, path)
+		}
+		if entry.RetiredAt == nil {
+			file.Keys[i].RetiredAt = &retiredAt
+		}
+	}
+	file.Keys = append([]keyRingEntry{{Kid: kid, PrivateKeyFile: keyFile}}, file.Keys...)
+
+	out, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write beside the original and rename so a server starting meanwhile
+	// never reads it half written. Running servers keep the ring they
+	// loaded until restarted.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(out, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rupesh-sengar/golang-collection/auth/keyring"
+	"github.com/youmark/pkcs8"
+)
+
+var (
+	testKeyOnce sync.Once
+	testKey     *rsa.PrivateKey
+)
+
+func rsaKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	testKeyOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			panic(err)
+		}
+		testKey = key
+	})
+	return testKey
+}
+
+func pemOf(blockType string, der []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}))
+}
+
+func jwkOf(key *rsa.PrivateKey, private bool) map[string]string {
+	enc := func(n *big.Int) string { return base64.RawURLEncoding.EncodeToString(n.Bytes()) }
+	m := map[string]string{
+		"kty": "RSA",
+		"kid": "test",
+		"n":   enc(key.N),
+		"e":   enc(big.NewInt(int64(key.E))),
+	}
+	if private {
+		m["d"] = enc(key.D)
+		m["p"] = enc(key.Primes[0])
+		m["q"] = enc(key.Primes[1])
+	}
+	return m
+}
+
+func jsonOf(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestParsePrivateKeyFormats(t *testing.T) {
+	key := rsaKey(t)
+	pkcs8DER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	passphrase := []byte("correct horse")
+	encryptedDER, err := pkcs8.MarshalPrivateKey(key, passphrase, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkcs8PEM := pemOf("PRIVATE KEY", pkcs8DER)
+	jwk := jsonOf(t, jwkOf(key, true))
+	tests := []struct {
+		name       string
+		input      string
+		passphrase []byte
+	}{
+		{"PKCS#8 PEM", pkcs8PEM, nil},
+		{"PKCS#8 PEM with surrounding whitespace", "\n  " + pkcs8PEM + "\n", nil},
+		{"base64 PKCS#8 PEM", base64.StdEncoding.EncodeToString([]byte(pkcs8PEM)), nil},
+		{"encrypted PKCS#8 PEM", pemOf("ENCRYPTED PRIVATE KEY", encryptedDER), passphrase},
+		{"PKCS#1 PEM", pemOf("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)), nil},
+		{"JWK", jwk, nil},
+		{"base64 JWK", base64.StdEncoding.EncodeToString([]byte(jwk)), nil},
+		{"JWKS with one private key", jsonOf(t, map[string]any{"keys": []any{jwkOf(key, false), jwkOf(key, true)}}), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePrivateKey(tt.input, tt.passphrase)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(key) {
+				t.Error("parsed key differs from the original")
+			}
+		})
+	}
+}
+
+func TestParsePrivateKeyErrors(t *testing.T) {
+	key := rsaKey(t)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDER, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptedDER, err := pkcs8.MarshalPrivateKey(key, []byte("correct horse"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := jwkOf(key, true)
+	tampered["d"] = base64.RawURLEncoding.EncodeToString([]byte("not the exponent"))
+
+	tests := []struct {
+		name       string
+		input      string
+		passphrase []byte
+		want       string
+	}{
+		{"not a key", "hello world", nil, "neither PEM, JWK JSON nor base64"},
+		{"base64 of something else", base64.StdEncoding.EncodeToString([]byte("hello")), nil, "neither PEM nor JWK"},
+		{"EC key", pemOf("PRIVATE KEY", ecDER), nil, "not an RSA key"},
+		{"public key PEM", pemOf("PUBLIC KEY", pubDER), nil, "not an RSA private key"},
+		{"encrypted without passphrase", pemOf("ENCRYPTED PRIVATE KEY", encryptedDER), nil, "no passphrase"},
+		{"encrypted with wrong passphrase", pemOf("ENCRYPTED PRIVATE KEY", encryptedDER), []byte("wrong"), "wrong passphrase"},
+		{"public JWK", jsonOf(t, jwkOf(key, false)), nil, `no "d" member`},
+		{"EC JWK", `{"kty":"EC","crv":"P-256"}`, nil, "not RSA"},
+		{"inconsistent JWK", jsonOf(t, tampered), nil, "detected JWK"},
+		{"JWKS without private keys", jsonOf(t, map[string]any{"keys": []any{jwkOf(key, false)}}), nil, "none is a private RSA key"},
+		{"JWKS with two private keys", jsonOf(t, map[string]any{"keys": []any{jwkOf(key, true), jwkOf(key, true)}}), nil, "KEY_RING_FILE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePrivateKey(tt.input, tt.passphrase)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadKeyRing(t *testing.T) {
+	key := rsaKey(t)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "old.pem"), []byte(pemOf("PRIVATE KEY", der)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	retired := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	ring := `{"keys": [
+		{"private_key": "` + base64.StdEncoding.EncodeToString([]byte(jsonOf(t, jwkOf(key, true)))) + `"},
+		{"kid": "old", "private_key_file": "old.pem", "retired_at": "` + retired + `"}
+	]}`
+	path := filepath.Join(dir, "ring.json")
+	if err := os.WriteFile(path, []byte(ring), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := LoadKeyRing(path, 24*time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := r.Primary().ID, keyring.Thumbprint(&key.PublicKey); got != want {
+		t.Errorf("primary kid = %q, want thumbprint %q", got, want)
+	}
+	if _, ok := r.Lookup("old"); !ok {
+		t.Error("retired key within grace not usable")
+	}
+}
+
+func TestLoadKeyRingErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"malformed.json":   `{"keys": [`,
+		"missing-key.json": `{"keys": [{"kid": "a", "private_key_file": "absent.pem"}]}`,
+		"bad-key.json":     `{"keys": [{"kid": "a", "private_key": "hello world"}]}`,
+		"empty.json":       `{"keys": []}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadKeyRing(path, time.Hour, nil); err == nil {
+				t.Error("LoadKeyRing succeeded")
+			}
+		})
+	}
+}
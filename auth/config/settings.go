@@ -0,0 +1,238 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+)
+
+// setting is one configurable value. key names it in config files and, as a
+// flag, on the command line; env names its environment variable.
+type setting struct {
+	key   string
+	env   string
+	usage string
+	set   func(raw string) error
+	// secret settings may also be read from the file named by <env>_FILE or
+	// from a file called <env> in the secrets directory.
+	secret bool
+	// pairSep is set for map-valued settings, which the environment and
+	// flags spell as "k<sep>v,k<sep>v" and files as a nested table.
+	pairSep string
+}
+
+type sourcedValue struct {
+	setting setting
+	source  string
+	raw     string
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{key: "server.port", env: "PORT", usage: "HTTP listen port", set: stringVar(&c.Server.Port)},
+
+		{key: "mongo.uri", env: "MONGODB_URI", usage: "MongoDB connection string", set: stringVar(&c.Mongo.URI), secret: true},
+		{key: "mongo.database", env: "MONGODB_DATABASE", usage: "MongoDB database", set: stringVar(&c.Mongo.Database)},
+		{key: "mongo.users_collection", env: "MONGODB_USERS_COLLECTION", usage: "users collection", set: stringVar(&c.Mongo.UsersCollection)},
+		{key: "mongo.refresh_tokens_collection", env: "MONGODB_REFRESH_TOKENS_COLLECTION", usage: "refresh tokens collection", set: stringVar(&c.Mongo.RefreshTokensCollection)},
+		{key: "mongo.revoked_tokens_collection", env: "MONGODB_REVOKED_TOKENS_COLLECTION", usage: "revoked tokens collection", set: stringVar(&c.Mongo.RevokedTokensCollection)},
+
+		{key: "keys.private_key", env: "PRIVATE_KEY", usage: "RSA private key (PEM or JWK, optionally base64)", set: stringVar(&c.Keys.PrivateKey), secret: true},
+		{key: "keys.passphrase", env: "PRIVATE_KEY_PASSPHRASE", usage: "passphrase for encrypted PKCS#8 keys", set: stringVar(&c.Keys.Passphrase), secret: true},
+		{key: "keys.ring_file", env: "KEY_RING_FILE", usage: "JSON key ring for key rotation", set: stringVar(&c.Keys.RingFile)},
+		{key: "keys.grace_period", env: "KEY_GRACE_PERIOD", usage: "how long retired keys stay usable", set: durationVar(&c.Keys.GracePeriod)},
+		{key: "keys.min_envelope_version", env: "ENC_PASSWORD_MIN_VERSION", usage: "oldest enc_password version accepted (0 PKCS#1 v1.5, 1 RSA-OAEP)", set: intVar(&c.Keys.MinEnvelopeVersion)},
+		{key: "keys.envelope_window", env: "ENC_PASSWORD_WINDOW", usage: "accepted enc_password timestamp skew", set: durationVar(&c.Keys.EnvelopeWindow)},
+
+		{key: "tokens.issuer", env: "TOKEN_ISSUER", usage: "iss claim of issued tokens", set: stringVar(&c.Tokens.Issuer)},
+		{key: "tokens.audience", env: "TOKEN_AUDIENCE", usage: "aud claim of access tokens", set: stringVar(&c.Tokens.Audience)},
+		{key: "tokens.access_ttl", env: "ACCESS_TOKEN_TTL", usage: "access token lifetime", set: durationVar(&c.Tokens.AccessTokenTTL)},
+		{key: "tokens.id_ttl", env: "ID_TOKEN_TTL", usage: "ID token lifetime", set: durationVar(&c.Tokens.IDTokenTTL)},
+		{key: "tokens.refresh_ttl", env: "REFRESH_TOKEN_TTL", usage: "refresh token lifetime", set: durationVar(&c.Tokens.RefreshTokenTTL)},
+		{key: "tokens.auth0_passthrough", env: "AUTH0_TOKEN_PASSTHROUGH", usage: "return Auth0's tokens instead of self-issued ones", set: boolVar(&c.Tokens.Auth0Passthrough)},
+
+		{key: "timeouts.request", env: "REQUEST_TIMEOUT", usage: "deadline for a whole HTTP request", set: durationVar(&c.Timeouts.Request)},
+		{key: "timeouts.database", env: "DB_TIMEOUT", usage: "deadline for one repository operation", set: durationVar(&c.Timeouts.Database)},
+		{key: "timeouts.auth0", env: "AUTH0_TIMEOUT", usage: "deadline for one Auth0 call", set: durationVar(&c.Timeouts.Auth0)},
+
+		{key: "password.algorithm", env: "PASSWORD_HASH_ALGORITHM", usage: "argon2id or bcrypt", set: func(raw string) error {
+			c.Password.Algorithm = domain.PasswordAlgorithm(raw)
+			return nil
+		}},
+		{key: "password.argon2_memory_kib", env: "PASSWORD_ARGON2_MEMORY_KIB", usage: "argon2id memory cost", set: uintVar(&c.Password.Argon2id.Memory, 32)},
+		{key: "password.argon2_iterations", env: "PASSWORD_ARGON2_ITERATIONS", usage: "argon2id time cost", set: uintVar(&c.Password.Argon2id.Iterations, 32)},
+		{key: "password.argon2_parallelism", env: "PASSWORD_ARGON2_PARALLELISM", usage: "argon2id lanes", set: uintVar(&c.Password.Argon2id.Parallelism, 8)},
+		{key: "password.bcrypt_cost", env: "PASSWORD_BCRYPT_COST", usage: "bcrypt cost", set: uintVar(&c.Password.BcryptCost, 8)},
+
+		{key: "identity.provider", env: "IDENTITY_PROVIDER", usage: "default identity provider, auth0 or local", set: stringVar(&c.Identity.Provider)},
+		{key: "identity.overrides", env: "IDENTITY_PROVIDER_OVERRIDES", usage: `per-application providers, e.g. "crm=local,shop=auth0"`, set: pairsVar(&c.Identity.Overrides, "="), pairSep: "="},
+
+		{key: "auth0.domain", env: "AUTH0_DOMAIN", usage: "Auth0 tenant domain", set: stringVar(&c.Auth0.Domain)},
+		{key: "auth0.audience", env: "AUTH0_AUDIENCE", usage: "Auth0 API audience", set: stringVar(&c.Auth0.Audience)},
+		{key: "auth0.client_id", env: "AUTH0_CLIENT_ID", usage: "Auth0 application client ID", set: stringVar(&c.Auth0.ClientID)},
+		{key: "auth0.client_secret", env: "AUTH0_CLIENT_SECRET", usage: "Auth0 application client secret", set: stringVar(&c.Auth0.ClientSecret), secret: true},
+
+		{key: "introspection.clients", env: "INTROSPECTION_CLIENTS", usage: `introspection credentials, e.g. "api:secret"`, set: pairsVar(&c.Introspection.Clients, ":"), pairSep: ":", secret: true},
+	}
+}
+
+// envValues collects every setting whose environment variable is non-empty,
+// and every secret whose <env>_FILE variable names a file to read it from.
+// Setting both is an error, since it is unclear which was meant.
+func envValues(settings []setting) ([]sourcedValue, []error) {
+	var (
+		values []sourcedValue
+		errs   []error
+	)
+	for _, s := range settings {
+		raw := os.Getenv(s.env)
+		path := ""
+		if s.secret {
+			path = os.Getenv(s.env + "_FILE")
+		}
+		switch {
+		case raw != "" && path != "":
+			errs = append(errs, fmt.Errorf("%s and %s_FILE are both set; use one", s.env, s.env))
+		case path != "":
+			raw, err := readSecret(path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s_FILE: %w", s.env, err))
+				continue
+			}
+			values = append(values, sourcedValue{setting: s, source: s.env + "_FILE", raw: raw})
+		case raw != "":
+			values = append(values, sourcedValue{setting: s, source: s.env, raw: raw})
+		}
+	}
+	return values, errs
+}
+
+// secretDirValues reads secrets mounted as files in dir, the layout Docker
+// and Kubernetes use for secrets: one file per secret, named after its
+// environment variable in upper or lower case (PRIVATE_KEY or private_key).
+// A missing dir is not an error.
+func secretDirValues(dir string, settings []setting) ([]sourcedValue, []error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, nil
+	}
+	var (
+		values []sourcedValue
+		errs   []error
+	)
+	for _, s := range settings {
+		if !s.secret {
+			continue
+		}
+		for _, name := range []string{s.env, strings.ToLower(s.env)} {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			raw, err := readSecret(path)
+			if err != nil {
+				errs = append(errs, err)
+				break
+			}
+			values = append(values, sourcedValue{setting: s, source: path, raw: raw})
+			break
+		}
+	}
+	return values, errs
+}
+
+// readSecret returns the contents of a secret file without the trailing
+// newline editors and `echo` add.
+func readSecret(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func apply(values []sourcedValue) []error {
+	var errs []error
+	for _, v := range values {
+		if err := v.setting.set(v.raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", v.source, err))
+		}
+	}
+	return errs
+}
+
+func stringVar(dst *string) func(string) error {
+	return func(raw string) error {
+		*dst = raw
+		return nil
+	}
+}
+
+func durationVar(dst *time.Duration) func(string) error {
+	return func(raw string) error {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		*dst = d
+		return nil
+	}
+}
+
+func boolVar(dst *bool) func(string) error {
+	return func(raw string) error {
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		*dst = b
+		return nil
+	}
+}
+
+func intVar(dst *int) func(string) error {
+	return func(raw string) error {
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		*dst = v
+		return nil
+	}
+}
+
+func uintVar[T ~uint8 | ~uint32 | ~int](dst *T, bits int) func(string) error {
+	return func(raw string) error {
+		v, err := strconv.ParseUint(raw, 10, bits)
+		if err != nil || v == 0 {
+			return fmt.Errorf("invalid positive integer %q", raw)
+		}
+		*dst = T(v)
+		return nil
+	}
+}
+
+// pairsVar parses a comma-separated list of key<sep>value pairs, replacing
+// the whole map so a later source fully overrides an earlier one.
+func pairsVar(dst *map[string]string, sep string) func(string) error {
+	return func(raw string) error {
+		pairs := map[string]string{}
+		if raw == "" {
+			*dst = pairs
+			return nil
+		}
+		for _, pair := range strings.Split(raw, ",") {
+			k, v, ok := strings.Cut(strings.TrimSpace(pair), sep)
+			if !ok || k == "" || v == "" {
+				return fmt.Errorf("invalid entry %q", pair)
+			}
+			pairs[k] = v
+		}
+		*dst = pairs
+		return nil
+	}
+}
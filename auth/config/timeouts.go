@@ -0,0 +1,14 @@
+package config
+
+import "time"
+
+// TimeoutSettings are the deadline budgets for each layer of a request. Each
+// budget only ever shortens the caller's deadline, never extends it.
+type TimeoutSettings struct {
+	// Request bounds a whole HTTP request, from controller to response.
+	Request time.Duration
+	// Database bounds a single repository operation.
+	Database time.Duration
+	// Auth0 bounds a single outbound call to Auth0.
+	Auth0 time.Duration
+}
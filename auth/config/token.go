@@ -0,0 +1,18 @@
+package config
+
+import "time"
+
+// TokenSettings controls the JWTs this service issues.
+type TokenSettings struct {
+	Issuer         string
+	Audience       string
+	AccessTokenTTL time.Duration
+	IDTokenTTL     time.Duration
+
+	// RefreshTokenTTL bounds each refresh token; rotation issues a new one
+	// with a fresh lifetime.
+	RefreshTokenTTL time.Duration
+	// Auth0Passthrough returns the identity provider's own tokens instead
+	// of self-issued ones when the provider supplies them.
+	Auth0Passthrough bool
+}
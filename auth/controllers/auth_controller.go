@@ -1,92 +1,142 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"github.com/rupesh-sengar/golang-collection/auth/middleware"
 	"github.com/rupesh-sengar/golang-collection/auth/services"
+	"github.com/rupesh-sengar/golang-collection/auth/token"
 	"github.com/rupesh-sengar/golang-collection/auth/utils"
 	"github.com/rupesh-sengar/golang-collection/auth/utils/types"
 	"net/http"
-	"strings"
 )
 
+// AuthController serves the authentication API on top of the injected
+// services.
+type AuthController struct {
+	auth      *services.AuthService
+	tokens    *services.TokenService
+	providers *services.ProviderRegistry
+	signer    *token.Signer
+	passwords *utils.PasswordDecrypter
+}
+
+func NewAuthController(auth *services.AuthService, tokens *services.TokenService, providers *services.ProviderRegistry, signer *token.Signer, passwords *utils.PasswordDecrypter) *AuthController {
+	return &AuthController{auth: auth, tokens: tokens, providers: providers, signer: signer, passwords: passwords}
+}
+
 type LoginRequest struct {
 	Username    string `json:"username" binding:"required"`
 	EncPassword string `json:"enc_password" binding:"required"`
 	Application string `json:"application" binding:"required"`
 }
 
-func LoginHandler(c *gin.Context) {
+func (ctl *AuthController) LoginHandler(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		c.Error(bindError(err))
+		return
+	}
+	status, err := ctl.auth.UserStatus(c.Request.Context(), req.Username, req.Application)
+	if errors.Is(err, domain.ErrNotFound) {
+		c.Error(services.ErrInvalidCredentials)
 		return
 	}
-	status, err:=utils.CheckUserStatus(req.Username)
-	fmt.Println("User status: ", status)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check user status", "detail": err.Error()})
+		c.Error(err)
 		return
 	}
-	if status != "approved"{
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not approved"})
+	if !status.CanAuthenticate() {
+		c.Error(fmt.Errorf("user not approved: %w", domain.ErrUnauthorized))
 		return
 	}
-	password, err := utils.DecryptEncPassword(req.EncPassword)
+	password, err := ctl.passwords.Decrypt(req.EncPassword)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Decryption failed", "detail": err.Error()})
+		c.Error(decryptError("enc_password", err))
 		return
 	}
 
-	token, err := services.Auth0Login(req.Username, password)
+	token, err := ctl.tokens.Login(c.Request.Context(), services.Credentials{
+		Username:    req.Username,
+		Password:    password,
+		Application: req.Application,
+	})
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Auth0 login failed", "detail": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, token)
 }
 
-func SignupHandler(c *gin.Context) {
+func (ctl *AuthController) SignupHandler(c *gin.Context) {
 	var req types.SignupRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		c.Error(bindError(err))
 		return
 	}
 
-	password, err := utils.DecryptEncPassword(req.Password)
+	password, err := ctl.passwords.Decrypt(req.Password)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Decryption failed", "detail": err.Error()})
+		c.Error(decryptError("password", err))
 		return
 	}
-	resp, err := services.Auth0Signup(req, password)
-	fmt.Println("Auth0 signup erro: ", err)
+	var creatorID string
+	if principal, ok := middleware.GetPrincipal(c); ok {
+		creatorID = principal.UserID
+	}
+	_, err = ctl.providers.For(req.Application).Register(c.Request.Context(), services.Registration{
+		FirstName:   req.FirstName,
+		LastName:    req.LastName,
+		Email:       req.Email,
+		Password:    password,
+		Application: req.Application,
+		CreatorID:   creatorID,
+	})
 	if err != nil {
-		if strings.Contains(err.Error(), "user already exists") || strings.Contains(err.Error(), "email already in use") {
-			c.JSON(http.StatusConflict, gin.H{"error": "User with this email already exists."})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Signup failed", "detail": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	fmt.Println("Auth0 signup response status:", resp)
-
 	c.JSON(http.StatusOK, gin.H{"message": "Signup successful"})
 
 }
 
-func AuthApprovalHandler(c *gin.Context) {
+func (ctl *AuthController) AuthApprovalHandler(c *gin.Context) {
 	var req types.AuthApprovalRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		c.Error(bindError(err))
 		return
 	}
 
-	if _, err := services.AuthApprovalService(req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Approval failed", "detail": err.Error()})
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		c.Error(domain.ErrUnauthorized)
+		return
+	}
+	// Admins are admins of their own application only.
+	if principal.Application == "" {
+		c.Error(fmt.Errorf("token names no application: %w", domain.ErrForbidden))
+		return
+	}
+
+	if err := ctl.auth.Approve(c.Request.Context(), req, principal.UserID, principal.Application); err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "User approved successfully"})
 }
+
+// decryptError reports an encrypted field that could not be decrypted. The
+// cause is logged rather than returned, since it describes the server key;
+// stale and replayed envelopes are reported as such.
+func decryptError(field string, err error) error {
+	if errors.Is(err, domain.ErrUnauthorized) {
+		return err
+	}
+	fmt.Println("Decryption failed:", err)
+	return domain.NewValidationError(field, "could not be decrypted")
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rupesh-sengar/golang-collection/auth/token"
+)
+
+// EncryptionKey is one public key clients may encrypt enc_password to.
+type EncryptionKey struct {
+	Kid string    `json:"kid"`
+	PEM string    `json:"pem"`
+	JWK token.JWK `json:"jwk"`
+	// ExpiresAt is set once the key is retired: envelopes encrypted to it
+	// stop being accepted at this time.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type EncryptionKeyResponse struct {
+	// Kid is the key new envelopes should be encrypted to.
+	Kid               string          `json:"kid"`
+	SupportedVersions []int           `json:"supported_versions"`
+	LatestVersion     int             `json:"latest_version"`
+	Keys              []EncryptionKey `json:"keys"`
+}
+
+// EncryptionKeyHandler publishes the login encryption keys so clients can
+// fetch them at runtime instead of shipping a PEM file, and pick up rotations
+// when the primary kid changes.
+func (ctl *AuthController) EncryptionKeyHandler(c *gin.Context) {
+	ring := ctl.passwords.Keys()
+	versions := ctl.passwords.SupportedVersions()
+	resp := EncryptionKeyResponse{
+		Kid:               ring.Primary().ID,
+		SupportedVersions: versions,
+		LatestVersion:     versions[len(versions)-1],
+	}
+
+	for _, k := range ring.Usable() {
+		der, err := x509.MarshalPKIXPublicKey(&k.Private.PublicKey)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		key := EncryptionKey{
+			Kid: k.ID,
+			PEM: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+			JWK: token.EncryptionJWK(k.ID, &k.Private.PublicKey),
+		}
+		if exp := ring.ExpiresAt(k); !exp.IsZero() {
+			key.ExpiresAt = &exp
+		}
+		resp.Keys = append(resp.Keys, key)
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IntrospectRequest struct {
+	Token         string `form:"token" json:"token" binding:"required"`
+	TokenTypeHint string `form:"token_type_hint" json:"token_type_hint"`
+}
+
+// IntrospectHandler is the RFC 7662 introspection endpoint. Callers
+// authenticate with HTTP Basic using an INTROSPECTION_CLIENTS credential.
+func (ctl *AuthController) IntrospectHandler(c *gin.Context) {
+	id, secret, ok := c.Request.BasicAuth()
+	if !ok || !ctl.tokens.AuthenticateClient(id, secret) {
+		c.Header("WWW-Authenticate", `Basic realm="introspect"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_client"})
+		return
+	}
+
+	var req IntrospectRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request"})
+		return
+	}
+
+	resp, err := ctl.tokens.Introspect(c.Request.Context(), req.Token, req.TokenTypeHint)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "temporarily_unavailable"})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+)
+
+// Gin checks binding tags with the domain validator, so bind failures come
+// back as *domain.ValidationError with JSON field names.
+func init() {
+	v, err := domain.NewValidator("binding")
+	if err != nil {
+		panic(err)
+	}
+	binding.Validator = v
+}
+
+// bindError turns a ShouldBind failure into a *domain.ValidationError naming
+// the offending fields.
+func bindError(err error) error {
+	var (
+		verr      *domain.ValidationError
+		typeErr   *json.UnmarshalTypeError
+		syntaxErr *json.SyntaxError
+	)
+	switch {
+	case errors.As(err, &verr):
+		return verr
+	case errors.As(err, &typeErr):
+		return &domain.ValidationError{Fields: []domain.FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   typeErr.Type.String(),
+			Message: fmt.Sprintf("%s must be a %s", typeErr.Field, typeErr.Type),
+		}}}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return domain.NewValidationError("body", "malformed JSON")
+	case errors.Is(err, io.EOF):
+		return domain.NewValidationError("body", "request body is empty")
+	}
+	return domain.NewValidationError("body", "invalid request body")
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (ctl *AuthController) JWKSHandler(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, ctl.signer.SigningKeys())
+}
+
+// OpenIDConfigurationHandler publishes the issuer, token endpoint, signing
+// algorithms and claims resource servers need to verify the service's
+// tokens. The token endpoint is the login API, which takes an encrypted
+// password rather than an OAuth token request.
+func (ctl *AuthController) OpenIDConfigurationHandler(c *gin.Context) {
+	issuer := ctl.signer.Settings().Issuer
+	base := baseURL(c, issuer)
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{
+		"issuer":                                issuer,
+		"jwks_uri":                              base + "/.well-known/jwks.json",
+		"token_endpoint":                        base + "/api/v1/login",
+		"response_types_supported":              []string{"id_token"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"claims_supported": []string{
+			"sub", "aud", "iss", "exp", "iat", "nbf", "jti",
+			"token_use", "scope", "email", "name", "roles", "application", "status",
+		},
+	})
+}
+
+// baseURL prefers the configured issuer when it is a URL, so discovery
+// documents stay consistent behind proxies, and falls back to the request.
+func baseURL(c *gin.Context, issuer string) string {
+	if strings.HasPrefix(issuer, "https://") || strings.HasPrefix(issuer, "http://") {
+		return strings.TrimSuffix(issuer, "/")
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
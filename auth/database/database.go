@@ -4,31 +4,28 @@ import (
 	"context"
 	"errors"
 	"log"
-	"os"
-	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
-var Client *mongo.Client
-
-func ConnectDB() error {
-	mongoURI := os.Getenv("MONGODB_URI")
+// Connect opens the pooled MongoDB client shared by the whole process.
+func Connect(ctx context.Context, mongoURI string) (*mongo.Client, error) {
 	if mongoURI == "" {
-		return errors.New("MONGO_URI environment variable not set")
+		return nil, errors.New("MongoDB URI not set")
 	}
 
 	clientOptions := options.Client().ApplyURI(mongoURI)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	var err error
-	Client, err = mongo.Connect(ctx, clientOptions)
+	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err := client.Ping(ctx, readpref.Primary()); err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
 	}
 
 	log.Println("Successfully connected to MongoDB!")
-	return nil
-}
\ No newline at end of file
+	return client, nil
+}
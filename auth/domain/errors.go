@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// Sentinel errors shared by every layer. Repositories and services wrap them
+// with context (fmt.Errorf("...: %w", ErrNotFound)); callers test with
+// errors.Is and the HTTP layer maps each to a status code.
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrConflict          = errors.New("conflict")
+	ErrVersionMismatch   = errors.New("version mismatch")
+	ErrInvalidTransition = errors.New("invalid status transition")
+	ErrUnauthorized      = errors.New("unauthorized")
+	ErrForbidden         = errors.New("forbidden")
+)
+
+// FieldError describes one invalid input field. Field is the dotted JSON
+// path, Rule and Param name the failed constraint (for example "min" and "2")
+// for clients that localize their own messages, and Message is the English
+// rendering.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule,omitempty"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when input fails validation. Fields lists every
+// failing field so clients can show all problems at once.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func NewValidationError(field, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
@@ -0,0 +1,245 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordAlgorithm names the key-derivation function a stored hash was produced with.
+type PasswordAlgorithm string
+
+const (
+	AlgorithmArgon2id PasswordAlgorithm = "argon2id"
+	AlgorithmBcrypt   PasswordAlgorithm = "bcrypt"
+)
+
+var ErrUnknownHashFormat = errors.New("unrecognised password hash format")
+
+// ErrInvalidHash reports an argon2id hash whose parameters are out of range.
+// argon2.IDKey panics on some of them, and others would let a tampered hash
+// make every login attempt allocate gigabytes.
+var ErrInvalidHash = errors.New("invalid argon2id hash parameters")
+
+// Bounds on the argon2id parameters a stored hash may carry, and so on the
+// ones a hasher may be configured with.
+const (
+	maxArgon2Memory     = 4 * 1024 * 1024 // KiB, i.e. 4 GiB
+	maxArgon2Iterations = 64
+	minArgon2KeyLength  = 16
+	maxArgon2KeyLength  = 1024
+)
+
+// Argon2idParams are the tunable argon2id costs. Memory is in KiB.
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// validate checks p against the argon2id bounds.
+func (p Argon2idParams) validate() error {
+	var errs []error
+	if p.Iterations < 1 || p.Iterations > maxArgon2Iterations {
+		errs = append(errs, fmt.Errorf("argon2id iterations must be between 1 and %d, got %d", maxArgon2Iterations, p.Iterations))
+	}
+	if p.Parallelism < 1 {
+		errs = append(errs, errors.New("argon2id parallelism must be at least 1"))
+	}
+	// argon2 needs at least 8 KiB of memory per lane.
+	if p.Memory < 8*uint32(p.Parallelism) || p.Memory > maxArgon2Memory {
+		errs = append(errs, fmt.Errorf("argon2id memory must be between %d KiB (8 per lane) and %d KiB, got %d", 8*uint32(p.Parallelism), maxArgon2Memory, p.Memory))
+	}
+	if p.SaltLength < 1 {
+		errs = append(errs, errors.New("argon2id salt length must be at least 1"))
+	}
+	if p.KeyLength < minArgon2KeyLength || p.KeyLength > maxArgon2KeyLength {
+		errs = append(errs, fmt.Errorf("argon2id key length must be between %d and %d, got %d", minArgon2KeyLength, maxArgon2KeyLength, p.KeyLength))
+	}
+	return errors.Join(errs...)
+}
+
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// PasswordHasher produces and checks self-describing password hashes.
+//
+// argon2id hashes use the PHC string format
+// ($argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>); bcrypt hashes use bcrypt's
+// own modular crypt format ($2a$<cost>$...), which records the cost in the
+// same way. Either can be verified regardless of which algorithm is currently
+// configured, so switching algorithms only affects newly written hashes.
+type PasswordHasher struct {
+	Algorithm  PasswordAlgorithm
+	Argon2id   Argon2idParams
+	BcryptCost int
+}
+
+// DefaultPasswordHasher returns the hasher configuration uses when no
+// password settings are given.
+func DefaultPasswordHasher() PasswordHasher {
+	return PasswordHasher{
+		Algorithm:  AlgorithmArgon2id,
+		Argon2id:   DefaultArgon2idParams,
+		BcryptCost: bcrypt.DefaultCost,
+	}
+}
+
+// Validate reports configuration h could not hash with, or whose argon2id
+// hashes Verify would reject as out of range.
+func (h *PasswordHasher) Validate() error {
+	var errs []error
+	switch h.Algorithm {
+	case AlgorithmArgon2id, AlgorithmBcrypt:
+	default:
+		errs = append(errs, fmt.Errorf("password hash algorithm must be argon2id or bcrypt, got %q", h.Algorithm))
+	}
+	if err := h.Argon2id.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if h.BcryptCost < bcrypt.MinCost || h.BcryptCost > bcrypt.MaxCost {
+		errs = append(errs, fmt.Errorf("bcrypt cost must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, h.BcryptCost))
+	}
+	return errors.Join(errs...)
+}
+
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	switch h.Algorithm {
+	case AlgorithmArgon2id, "":
+		return h.hashArgon2id(password)
+	case AlgorithmBcrypt:
+		b, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	default:
+		return "", fmt.Errorf("unsupported password algorithm %q", h.Algorithm)
+	}
+}
+
+// Verify reports whether password matches encoded. A mismatch is (false, nil);
+// an error means encoded could not be parsed.
+func (h *PasswordHasher) Verify(password, encoded string) (bool, error) {
+	switch algorithmOf(encoded) {
+	case AlgorithmArgon2id:
+		p, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return false, err
+		}
+		other := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+		return subtle.ConstantTimeCompare(key, other) == 1, nil
+	case AlgorithmBcrypt:
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	default:
+		return false, ErrUnknownHashFormat
+	}
+}
+
+// NeedsRehash reports whether encoded was produced with a different algorithm
+// or weaker parameters than h is configured for. Unparseable values always
+// need a rehash.
+func (h *PasswordHasher) NeedsRehash(encoded string) bool {
+	want := h.Algorithm
+	if want == "" {
+		want = AlgorithmArgon2id
+	}
+	if algorithmOf(encoded) != want {
+		return true
+	}
+
+	switch want {
+	case AlgorithmArgon2id:
+		p, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return true
+		}
+		return p.Memory != h.Argon2id.Memory ||
+			p.Iterations != h.Argon2id.Iterations ||
+			p.Parallelism != h.Argon2id.Parallelism ||
+			uint32(len(salt)) != h.Argon2id.SaltLength ||
+			uint32(len(key)) != h.Argon2id.KeyLength
+	case AlgorithmBcrypt:
+		cost, err := bcrypt.Cost([]byte(encoded))
+		return err != nil || cost != h.BcryptCost
+	}
+	return true
+}
+
+func (h *PasswordHasher) hashArgon2id(password string) (string, error) {
+	p := h.Argon2id
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func algorithmOf(encoded string) PasswordAlgorithm {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return AlgorithmArgon2id
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return AlgorithmBcrypt
+	default:
+		return ""
+	}
+}
+
+func decodeArgon2id(encoded string) (Argon2idParams, []byte, []byte, error) {
+	var p Argon2idParams
+
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, hash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+	if version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+	if p.validate() != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	return p, salt, key, nil
+}
@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testHasher keeps argon2id cheap so the tests run quickly.
+func testHasher() *PasswordHasher {
+	h := DefaultPasswordHasher()
+	h.Argon2id.Memory = 64
+	h.Argon2id.Iterations = 1
+	h.Argon2id.Parallelism = 1
+	h.BcryptCost = 4
+	return &h
+}
+
+func TestPasswordHashRoundTrip(t *testing.T) {
+	for _, alg := range []PasswordAlgorithm{AlgorithmArgon2id, AlgorithmBcrypt} {
+		t.Run(string(alg), func(t *testing.T) {
+			h := testHasher()
+			h.Algorithm = alg
+			encoded, err := h.Hash("correct horse")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := h.Verify("correct horse", encoded); !ok || err != nil {
+				t.Errorf("Verify(right password) = %v, %v", ok, err)
+			}
+			if ok, err := h.Verify("wrong horse", encoded); ok || err != nil {
+				t.Errorf("Verify(wrong password) = %v, %v", ok, err)
+			}
+			if h.NeedsRehash(encoded) {
+				t.Error("fresh hash needs rehash")
+			}
+		})
+	}
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	old := testHasher()
+	encoded, err := old.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stronger := testHasher()
+	stronger.Argon2id.Iterations = 2
+	if !stronger.NeedsRehash(encoded) {
+		t.Error("hash with fewer iterations does not need rehash")
+	}
+
+	bcryptHasher := testHasher()
+	bcryptHasher.Algorithm = AlgorithmBcrypt
+	if !bcryptHasher.NeedsRehash(encoded) {
+		t.Error("argon2id hash does not need rehash under bcrypt")
+	}
+	// Hashes stay verifiable after the algorithm changes.
+	if ok, err := bcryptHasher.Verify("correct horse", encoded); !ok || err != nil {
+		t.Errorf("Verify across algorithms = %v, %v", ok, err)
+	}
+
+	if !old.NeedsRehash("plaintext") {
+		t.Error("unhashed value does not need rehash")
+	}
+}
+
+func TestVerifyRejectsMalformedArgon2id(t *testing.T) {
+	const salt, key = "c2FsdHNhbHRzYWx0c2FsdA", "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+	tests := []struct {
+		name    string
+		params  string
+		key     string
+		wantErr error
+	}{
+		{"zero iterations", "m=64,t=0,p=1", key, ErrInvalidHash},
+		{"zero parallelism", "m=64,t=1,p=0", key, ErrInvalidHash},
+		{"too little memory", "m=4,t=1,p=1", key, ErrInvalidHash},
+		{"too much memory", "m=4294967295,t=1,p=1", key, ErrInvalidHash},
+		{"too many iterations", "m=64,t=100000,p=1", key, ErrInvalidHash},
+		{"short key", "m=64,t=1,p=1", "a2V5", ErrInvalidHash},
+		{"garbled params", "m=64;t=1;p=1", key, ErrUnknownHashFormat},
+		{"bad base64", "m=64,t=1,p=1", "!!!", ErrUnknownHashFormat},
+	}
+	h := testHasher()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := strings.Join([]string{"", "argon2id", "v=19", tt.params, salt, tt.key}, "$")
+			ok, err := h.Verify("password", encoded)
+			if ok || !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify = %v, %v; want false, %v", ok, err, tt.wantErr)
+			}
+			if !h.NeedsRehash(encoded) {
+				t.Error("malformed hash does not need rehash")
+			}
+		})
+	}
+}
+
+func TestVerifyUnknownFormat(t *testing.T) {
+	if _, err := testHasher().Verify("password", "password"); !errors.Is(err, ErrUnknownHashFormat) {
+		t.Errorf("err = %v, want ErrUnknownHashFormat", err)
+	}
+}
+
+func TestPasswordHasherValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(h *PasswordHasher)
+		wantErr string
+	}{
+		{"default", func(h *PasswordHasher) {}, ""},
+		{"unknown algorithm", func(h *PasswordHasher) { h.Algorithm = "md5" }, "algorithm"},
+		{"zero iterations", func(h *PasswordHasher) { h.Argon2id.Iterations = 0 }, "iterations"},
+		{"too many iterations", func(h *PasswordHasher) { h.Argon2id.Iterations = maxArgon2Iterations + 1 }, "iterations"},
+		{"zero parallelism", func(h *PasswordHasher) { h.Argon2id.Parallelism = 0 }, "parallelism"},
+		{"too little memory per lane", func(h *PasswordHasher) { h.Argon2id.Memory = 8; h.Argon2id.Parallelism = 2 }, "memory"},
+		{"too much memory", func(h *PasswordHasher) { h.Argon2id.Memory = maxArgon2Memory + 1 }, "memory"},
+		{"short key", func(h *PasswordHasher) { h.Argon2id.KeyLength = minArgon2KeyLength - 1 }, "key length"},
+		{"bcrypt cost too low", func(h *PasswordHasher) { h.BcryptCost = 3 }, "bcrypt cost"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := DefaultPasswordHasher()
+			tt.modify(&h)
+			err := h.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("err = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefreshToken is the stored record of an opaque refresh token. Only the
+// SHA-256 of the token is kept. Every login starts a new family; each
+// rotation adds a token to it, so replaying a used token can revoke every
+// descendant at once.
+type RefreshToken struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Hash        string             `bson:"hash" json:"-"`
+	FamilyID    string             `bson:"familyId" json:"familyId"`
+	UserID      primitive.ObjectID `bson:"userId" json:"userId"`
+	Application string             `bson:"application" json:"application"`
+	IssuedAt    time.Time          `bson:"issuedAt" json:"issuedAt"`
+	ExpiresAt   time.Time          `bson:"expiresAt" json:"expiresAt"`
+	UsedAt      *time.Time         `bson:"usedAt,omitempty" json:"usedAt,omitempty"`
+	Revoked     bool               `bson:"revoked" json:"revoked"`
+}
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, t *RefreshToken) error
+
+	FindByHash(ctx context.Context, hash string) (*RefreshToken, error)
+
+	// MarkUsed flags the token as consumed. It reports false when the token
+	// was already used or revoked, so concurrent refreshes cannot both win.
+	MarkUsed(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error)
+
+	RevokeFamily(ctx context.Context, familyID string) error
+}
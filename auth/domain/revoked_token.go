@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// RevokedToken records the ID (jti) of a JWT that must no longer be accepted.
+// It only needs to live until the token would have expired anyway.
+type RevokedToken struct {
+	ID        string    `bson:"_id" json:"jti"`
+	Subject   string    `bson:"subject" json:"sub"`
+	RevokedAt time.Time `bson:"revokedAt" json:"revokedAt"`
+	ExpiresAt time.Time `bson:"expiresAt" json:"expiresAt"`
+}
+
+type RevocationRepository interface {
+	Revoke(ctx context.Context, t *RevokedToken) error
+
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
@@ -48,13 +48,14 @@ const (
 	StatusActive    UserStatus = "active"
 	StatusSuspended UserStatus = "suspended"
 	StatusApproved  UserStatus = "approved"
+	StatusRejected  UserStatus = "rejected"
 )
 
 // StatusValidator ensures the status field is one of the predefined states.
 func StatusValidator(fl validator.FieldLevel) bool {
 	status := UserStatus(fl.Field().String())
 	switch status {
-	case StatusPending, StatusActive, StatusSuspended, StatusApproved:
+	case StatusPending, StatusActive, StatusSuspended, StatusApproved, StatusRejected:
 		return true
 	default:
 		return false
@@ -87,12 +88,26 @@ type User struct {
 	Audit       Audit                  `bson:"audit" json:"audit"`
 }
 
-func NewUser(first, last, rawEmail, plainPassword string, creatorID string, applicationID string) (*User, error) {
+const MinPasswordLength = 8
+
+// NewUser builds a pending member whose password is hashed with passwords.
+// creatorID is the authenticated actor creating the account; an empty
+// creatorID records a self-registration, with the new user as its own
+// creator.
+func NewUser(passwords *PasswordHasher, first, last, rawEmail, plainPassword string, creatorID string, applicationID string) (*User, error) {
+	if len(plainPassword) < MinPasswordLength {
+		return nil, NewValidationError("password", "must be at least 8 characters")
+	}
+	hash, err := passwords.Hash(plainPassword)
+	if err != nil {
+		return nil, err
+	}
+
 	u := &User{
 		ID:          primitive.NewObjectID(),
 		Name:        Name{First: first, Last: last},
 		Email:       Email(rawEmail),
-		Password:    HashPassword(plainPassword),
+		Password:    hash,
 		Roles:       []UserRole{RoleMember},
 		Application: applicationID,
 		ApprovedBy:  "",
@@ -107,6 +122,10 @@ func NewUser(first, last, rawEmail, plainPassword string, creatorID string, appl
 			Deleted:   false,
 		},
 	}
+	if creatorID == "" {
+		u.Audit.CreatedBy = u.ID.Hex()
+		u.Audit.UpdatedBy = u.ID.Hex()
+	}
 	if err := u.Validate(); err != nil {
 		return nil, err
 	}
@@ -114,18 +133,26 @@ func NewUser(first, last, rawEmail, plainPassword string, creatorID string, appl
 }
 
 func (u *User) Validate() error {
-	v := validator.New()
-
-	v.RegisterValidation("emailVO", func(fl validator.FieldLevel) bool {
-		return Email(fl.Field().String()).Validate() == nil
-	})
+	return ValidateStruct(u)
+}
 
-	v.RegisterValidation("role", RoleValidator)
+// SetPassword replaces the stored hash with a fresh hash of plain.
+func (u *User) SetPassword(passwords *PasswordHasher, plain string) error {
+	hash, err := passwords.Hash(plain)
+	if err != nil {
+		return err
+	}
+	u.Password = hash
+	return nil
+}
 
-	v.RegisterValidation("status", StatusValidator)
-	return v.Struct(u)
+// CheckPassword verifies plain against the stored hash.
+func (u *User) CheckPassword(passwords *PasswordHasher, plain string) (bool, error) {
+	return passwords.Verify(plain, u.Password)
 }
 
-func HashPassword(pw string) string {
-	return pw
+// PasswordNeedsRehash reports whether the stored hash should be upgraded to
+// the algorithm and parameters passwords is configured with.
+func (u *User) PasswordNeedsRehash(passwords *PasswordHasher) bool {
+	return passwords.NeedsRehash(u.Password)
 }
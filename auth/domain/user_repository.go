@@ -5,20 +5,37 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// UserFilter selects users for List. Zero fields match every user.
+type UserFilter struct {
+	Application string
+	Email       Email
+	Status      UserStatus
+	// Deleted lists soft-deleted users instead of live ones.
+	Deleted bool
+}
+
 type UserRepository interface {
 	Create(ctx context.Context, u *User) error
 
 	FindByID(ctx context.Context, id primitive.ObjectID) (*User, error)
 
-	FindByEmail(ctx context.Context, email Email) (*User, error)
+	// FindByEmail finds a live user by email within application. Emails
+	// are only unique per application, so there is no lookup across them.
+	FindByEmail(ctx context.Context, email Email, application string) (*User, error)
 
 	FindByApplication(ctx context.Context, applicationID string) ([]*User, error)
 
-	Approve(ctx context.Context, id primitive.ObjectID, approverID string) error
+	List(ctx context.Context, filter UserFilter) ([]*User, error)
+
+	// UpdateStatus persists a status transition already applied to u, but
+	// only while the stored status is still from. Otherwise it returns
+	// ErrInvalidTransition, so concurrent transitions cannot overwrite
+	// each other.
+	UpdateStatus(ctx context.Context, u *User, from UserStatus) error
 
-	UpdateStatus(ctx context.Context, id primitive.ObjectID, newStatus UserStatus, actorID string) error
+	Delete(ctx context.Context, id primitive.ObjectID, actorID string) error
 
-	Delete(ctx context.Context, id primitive.ObjectID, actorID primitive.ObjectID) error
+	Restore(ctx context.Context, id primitive.ObjectID, actorID string) error
 
 	Update(ctx context.Context, u *User, actorID string) error
 }
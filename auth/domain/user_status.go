@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// statusTransitions lists, for each status, the statuses a user may move to.
+// Every status change goes through User.transition, which checks this table.
+//
+//	pending   -> approved  (Approve)
+//	pending   -> rejected  (Reject)
+//	approved  -> active    (Activate, on first login)
+//	approved  -> suspended (Suspend)
+//	active    -> suspended (Suspend)
+//	suspended -> active    (Reinstate)
+var statusTransitions = map[UserStatus][]UserStatus{
+	StatusPending:   {StatusApproved, StatusRejected},
+	StatusApproved:  {StatusActive, StatusSuspended},
+	StatusActive:    {StatusSuspended},
+	StatusSuspended: {StatusActive},
+	StatusRejected:  {},
+}
+
+// CanTransitionTo reports whether a user may move from s to next.
+func (s UserStatus) CanTransitionTo(next UserStatus) bool {
+	for _, allowed := range statusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAuthenticate reports whether users with status s may log in and keep
+// using their tokens.
+func (s UserStatus) CanAuthenticate() bool {
+	return s == StatusApproved || s == StatusActive
+}
+
+// Approve lets a pending user log in. approverID is recorded as ApprovedBy.
+func (u *User) Approve(approverID string) error {
+	if err := u.transition(StatusApproved, approverID); err != nil {
+		return err
+	}
+	u.ApprovedBy = approverID
+	return nil
+}
+
+// Activate marks an approved user as having started using their account.
+func (u *User) Activate(actorID string) error {
+	return u.transition(StatusActive, actorID)
+}
+
+// Suspend blocks an approved or active user from logging in.
+func (u *User) Suspend(actorID string) error {
+	return u.transition(StatusSuspended, actorID)
+}
+
+// Reinstate lifts a suspension.
+func (u *User) Reinstate(actorID string) error {
+	return u.transition(StatusActive, actorID)
+}
+
+// Reject turns down a pending registration for good.
+func (u *User) Reject(actorID string) error {
+	return u.transition(StatusRejected, actorID)
+}
+
+// transition moves u to next and stamps the audit fields. It only changes u
+// in memory; UserRepository.UpdateStatus persists it.
+func (u *User) transition(next UserStatus, actorID string) error {
+	if !u.Status.CanTransitionTo(next) {
+		return fmt.Errorf("cannot move user from %s to %s: %w", u.Status, next, ErrInvalidTransition)
+	}
+	u.Status = next
+	u.Audit.UpdatedAt = time.Now().UTC()
+	u.Audit.UpdatedBy = actorID
+	return nil
+}
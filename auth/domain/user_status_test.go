@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUserTransitions(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   UserStatus
+		change func(u *User) error
+		want   UserStatus
+		ok     bool
+	}{
+		{"approve pending", StatusPending, func(u *User) error { return u.Approve("admin") }, StatusApproved, true},
+		{"reject pending", StatusPending, func(u *User) error { return u.Reject("admin") }, StatusRejected, true},
+		{"activate approved", StatusApproved, func(u *User) error { return u.Activate("user") }, StatusActive, true},
+		{"suspend approved", StatusApproved, func(u *User) error { return u.Suspend("admin") }, StatusSuspended, true},
+		{"suspend active", StatusActive, func(u *User) error { return u.Suspend("admin") }, StatusSuspended, true},
+		{"reinstate suspended", StatusSuspended, func(u *User) error { return u.Reinstate("admin") }, StatusActive, true},
+
+		{"activate pending", StatusPending, func(u *User) error { return u.Activate("user") }, StatusPending, false},
+		{"suspend pending", StatusPending, func(u *User) error { return u.Suspend("admin") }, StatusPending, false},
+		{"approve active", StatusActive, func(u *User) error { return u.Approve("admin") }, StatusActive, false},
+		{"reinstate active", StatusActive, func(u *User) error { return u.Reinstate("admin") }, StatusActive, false},
+		{"approve suspended", StatusSuspended, func(u *User) error { return u.Approve("admin") }, StatusSuspended, false},
+		{"approve rejected", StatusRejected, func(u *User) error { return u.Approve("admin") }, StatusRejected, false},
+		{"reinstate rejected", StatusRejected, func(u *User) error { return u.Reinstate("admin") }, StatusRejected, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &User{Status: tt.from, Audit: Audit{UpdatedBy: "creator"}}
+			err := tt.change(u)
+			if tt.ok && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.ok {
+				if !errors.Is(err, ErrInvalidTransition) {
+					t.Fatalf("err = %v, want ErrInvalidTransition", err)
+				}
+				if u.Audit.UpdatedBy != "creator" {
+					t.Errorf("rejected transition stamped UpdatedBy = %q", u.Audit.UpdatedBy)
+				}
+			}
+			if u.Status != tt.want {
+				t.Errorf("status = %s, want %s", u.Status, tt.want)
+			}
+		})
+	}
+}
+
+func TestApproveRecordsApprover(t *testing.T) {
+	u := &User{Status: StatusPending}
+	if err := u.Approve("admin-1"); err != nil {
+		t.Fatal(err)
+	}
+	if u.ApprovedBy != "admin-1" || u.Audit.UpdatedBy != "admin-1" {
+		t.Errorf("ApprovedBy = %q, UpdatedBy = %q, want admin-1", u.ApprovedBy, u.Audit.UpdatedBy)
+	}
+	if u.Audit.UpdatedAt.IsZero() {
+		t.Error("UpdatedAt not stamped")
+	}
+}
+
+func TestCanAuthenticate(t *testing.T) {
+	for status, want := range map[UserStatus]bool{
+		StatusPending:   false,
+		StatusApproved:  true,
+		StatusActive:    true,
+		StatusSuspended: false,
+		StatusRejected:  false,
+		"":              false,
+	} {
+		if got := status.CanAuthenticate(); got != want {
+			t.Errorf("%q.CanAuthenticate() = %v, want %v", status, got, want)
+		}
+	}
+}
+
+// Every status must appear in the table, or users in it would be stuck
+// without anyone noticing.
+func TestEveryStatusHasTransitions(t *testing.T) {
+	for _, s := range []UserStatus{StatusPending, StatusApproved, StatusActive, StatusSuspended, StatusRejected} {
+		if _, ok := statusTransitions[s]; !ok {
+			t.Errorf("status %s missing from statusTransitions", s)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/locales/en"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+)
+
+// Validator wraps go-playground/validator with the domain's custom tags,
+// JSON field names and English messages, and reports failures as
+// *ValidationError. It also satisfies Gin's binding.StructValidator, so bind
+// and domain failures read the same way.
+type Validator struct {
+	v     *validator.Validate
+	trans ut.Translator
+}
+
+// NewValidator builds a Validator reading constraints from tagName
+// ("validate" for domain entities, "binding" for Gin request structs).
+func NewValidator(tagName string) (*Validator, error) {
+	v := validator.New()
+	v.SetTagName(tagName)
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		// An empty name makes validator fall back to the Go field name.
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	v.RegisterValidation("emailVO", func(fl validator.FieldLevel) bool {
+		return Email(fl.Field().String()).Validate() == nil
+	})
+	v.RegisterValidation("role", RoleValidator)
+	v.RegisterValidation("status", StatusValidator)
+
+	// Translations are registered per translator, so each validator gets its own.
+	trans, _ := ut.New(en.New(), en.New()).GetTranslator("en")
+	if err := en_translations.RegisterDefaultTranslations(v, trans); err != nil {
+		return nil, err
+	}
+	for tag, text := range map[string]string{
+		"emailVO": "{0} must be a valid email address",
+		"role":    "{0} must be one of admin, member, guest",
+		"status":  "{0} must be one of pending, approved, active, suspended, rejected",
+	} {
+		err := v.RegisterTranslation(tag, trans,
+			func(t ut.Translator) error { return t.Add(tag, text, true) },
+			func(t ut.Translator, fe validator.FieldError) string {
+				msg, _ := t.T(fe.Tag(), fe.Field())
+				return msg
+			})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Validator{v: v, trans: trans}, nil
+}
+
+// ValidateStruct validates obj, which may be a struct or a pointer to one.
+// Other kinds are accepted as-is, matching Gin's default validator.
+func (val *Validator) ValidateStruct(obj any) error {
+	if obj == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(obj)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	return val.convert(val.v.Struct(obj))
+}
+
+// Engine returns the underlying *validator.Validate.
+func (val *Validator) Engine() any {
+	return val.v
+}
+
+// convert turns validator errors into a *ValidationError whose fields are
+// dotted JSON paths such as "name.first". Other errors are returned unchanged.
+func (val *Validator) convert(err error) error {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+	verr := &ValidationError{}
+	for _, fe := range fieldErrs {
+		verr.Fields = append(verr.Fields, FieldError{
+			Field:   fieldPath(fe.Namespace()),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fe.Translate(val.trans),
+		})
+	}
+	return verr
+}
+
+// fieldPath drops the root struct name validator puts at the front of a
+// namespace, leaving the path a client would see in the JSON body.
+func fieldPath(namespace string) string {
+	if _, rest, ok := strings.Cut(namespace, "."); ok {
+		return rest
+	}
+	return namespace
+}
+
+var entityValidator = sync.OnceValue(func() *Validator {
+	val, err := NewValidator("validate")
+	if err != nil {
+		panic(err)
+	}
+	return val
+})
+
+// ValidateStruct validates s against its validate tags and returns a
+// *ValidationError listing every failing field.
+func ValidateStruct(s any) error {
+	return entityValidator().ValidateStruct(s)
+}
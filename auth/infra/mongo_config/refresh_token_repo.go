@@ -0,0 +1,93 @@
+package mongo_config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type refreshTokenRepo struct {
+	coll    *mongo.Collection
+	timeout time.Duration
+}
+
+func EnsureRefreshTokenIndexes(ctx context.Context, coll *mongo.Collection) error {
+	models := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "familyId", Value: 1}},
+			Options: options.Index().SetName("family_idx"),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetName("expires_ttl").SetExpireAfterSeconds(0),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, models)
+	return err
+}
+
+func NewRefreshTokenRepository(coll *mongo.Collection, timeout time.Duration) domain.RefreshTokenRepository {
+	return &refreshTokenRepo{coll: coll, timeout: timeout}
+}
+
+func (r *refreshTokenRepo) Create(ctx context.Context, t *domain.RefreshToken) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if t.ID.IsZero() {
+		t.ID = primitive.NewObjectID()
+	}
+	_, err := r.coll.InsertOne(ctx, t)
+	return err
+}
+
+func (r *refreshTokenRepo) FindByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var t domain.RefreshToken
+	err := r.coll.FindOne(ctx, bson.M{"hash": hash}).Decode(&t)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("refresh token: %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *refreshTokenRepo) MarkUsed(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "usedAt": bson.M{"$exists": false}, "revoked": false},
+		bson.M{"$set": bson.M{"usedAt": at}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (r *refreshTokenRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.coll.UpdateMany(ctx,
+		bson.M{"familyId": familyID},
+		bson.M{"$set": bson.M{"revoked": true}},
+	)
+	return err
+}
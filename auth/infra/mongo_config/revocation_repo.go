@@ -0,0 +1,50 @@
+package mongo_config
+
+import (
+	"context"
+	"time"
+
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type revocationRepo struct {
+	coll    *mongo.Collection
+	timeout time.Duration
+}
+
+func EnsureRevocationIndexes(ctx context.Context, coll *mongo.Collection) error {
+	models := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetName("expires_ttl").SetExpireAfterSeconds(0),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, models)
+	return err
+}
+
+func NewRevocationRepository(coll *mongo.Collection, timeout time.Duration) domain.RevocationRepository {
+	return &revocationRepo{coll: coll, timeout: timeout}
+}
+
+func (r *revocationRepo) Revoke(ctx context.Context, t *domain.RevokedToken) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.coll.ReplaceOne(ctx, bson.M{"_id": t.ID}, t, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *revocationRepo) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	n, err := r.coll.CountDocuments(ctx, bson.M{"_id": jti}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
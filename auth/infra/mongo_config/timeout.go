@@ -0,0 +1,15 @@
+package mongo_config
+
+import (
+	"context"
+	"time"
+)
+
+// withTimeout bounds a single repository operation. The caller's deadline
+// still applies if it is sooner.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
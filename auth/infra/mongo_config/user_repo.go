@@ -3,6 +3,7 @@ package mongo_config
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/rupesh-sengar/golang-collection/auth/domain"
@@ -13,7 +14,8 @@ import (
 )
 
 type userRepo struct {
-	coll *mongo.Collection
+	coll    *mongo.Collection
+	timeout time.Duration
 }
 
 func EnsureUserIndexes(ctx context.Context, coll *mongo.Collection) error {
@@ -31,34 +33,71 @@ func EnsureUserIndexes(ctx context.Context, coll *mongo.Collection) error {
 	return err
 }
 
-func NewUserRepository(coll *mongo.Collection) domain.UserRepository {
-	return &userRepo{coll: coll}
+func NewUserRepository(coll *mongo.Collection, timeout time.Duration) domain.UserRepository {
+	return &userRepo{coll: coll, timeout: timeout}
 }
 
 func (r *userRepo) Create(ctx context.Context, u *domain.User) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	_, err := r.coll.InsertOne(ctx, u)
 	if mongo.IsDuplicateKeyError(err) {
-		return errors.New("email already in use for this application")
+		return fmt.Errorf("email already in use for this application: %w", domain.ErrConflict)
 	}
 	return err
 }
 
 func (r *userRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*domain.User, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var u domain.User
 	err := r.coll.FindOne(ctx, bson.M{"_id": id, "audit.deleted": false}).Decode(&u)
-	return &u, err
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("user %s: %w", id.Hex(), domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
 }
 
-func (r *userRepo) FindByEmail(ctx context.Context, email domain.Email) (*domain.User, error) {
+func (r *userRepo) FindByEmail(ctx context.Context, email domain.Email, application string) (*domain.User, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var u domain.User
-	filter := bson.M{"email": email, "audit.deleted": false}
+	filter := bson.M{"email": email, "application": application, "audit.deleted": false}
 	err := r.coll.FindOne(ctx, filter).Decode(&u)
-	return &u, err
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("user %s in application %s: %w", email, application, domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
 }
 
 func (r *userRepo) FindByApplication(ctx context.Context, applicationID string) ([]*domain.User, error) {
-	filter := bson.M{"application": applicationID, "audit.deleted": false}
-	cursor, err := r.coll.Find(ctx, filter)
+	return r.List(ctx, domain.UserFilter{Application: applicationID})
+}
+
+func (r *userRepo) List(ctx context.Context, f domain.UserFilter) ([]*domain.User, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.M{"audit.deleted": f.Deleted}
+	if f.Application != "" {
+		filter["application"] = f.Application
+	}
+	if f.Email != "" {
+		filter["email"] = f.Email
+	}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	cursor, err := r.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "audit.createdAt", Value: 1}}))
 	if err != nil {
 		return nil, err
 	}
@@ -75,65 +114,68 @@ func (r *userRepo) FindByApplication(ctx context.Context, applicationID string)
 	return users, cursor.Err()
 }
 
-func (r *userRepo) Approve(ctx context.Context, id primitive.ObjectID, approverID string) error {
+func (r *userRepo) UpdateStatus(ctx context.Context, u *domain.User, from domain.UserStatus) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	update := bson.M{
 		"$set": bson.M{
-			"approvedBy":      approverID,
-			"status":          domain.StatusApproved,
-			"audit.updatedAt": time.Now().UTC(),
-			"audit.updatedBy": approverID,
+			"status":          u.Status,
+			"approvedBy":      u.ApprovedBy,
+			"audit.updatedAt": u.Audit.UpdatedAt,
+			"audit.updatedBy": u.Audit.UpdatedBy,
 		},
 		"$inc": bson.M{"audit.version": 1},
 	}
 	res, err := r.coll.UpdateOne(ctx,
-		bson.M{"_id": id, "audit.deleted": false, "status": domain.StatusPending},
+		bson.M{"_id": u.ID, "audit.deleted": false, "status": from},
 		update,
 	)
 	if err != nil {
 		return err
 	}
 	if res.MatchedCount == 0 {
-		return errors.New("user not found or not pending approval")
+		return r.explainMiss(ctx, u.ID, fmt.Errorf("user %s is no longer %s: %w", u.ID.Hex(), from, domain.ErrInvalidTransition))
 	}
+	u.Audit.Version++
 	return nil
 }
 
-func (r *userRepo) UpdateStatus(ctx context.Context, id primitive.ObjectID, newStatus domain.UserStatus, actorID string) error {
+func (r *userRepo) Delete(ctx context.Context, id primitive.ObjectID, actorID string) error {
+	return r.setDeleted(ctx, id, true, actorID)
+}
+
+// Restore undoes a soft delete.
+func (r *userRepo) Restore(ctx context.Context, id primitive.ObjectID, actorID string) error {
+	return r.setDeleted(ctx, id, false, actorID)
+}
+
+func (r *userRepo) setDeleted(ctx context.Context, id primitive.ObjectID, deleted bool, actorID string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	update := bson.M{
 		"$set": bson.M{
-			"status":          newStatus,
+			"audit.deleted":   deleted,
 			"audit.updatedAt": time.Now().UTC(),
 			"audit.updatedBy": actorID,
 		},
 		"$inc": bson.M{"audit.version": 1},
 	}
-	res, err := r.coll.UpdateOne(ctx,
-		bson.M{"_id": id, "audit.deleted": false},
-		update,
-	)
+	res, err := r.coll.UpdateOne(ctx, bson.M{"_id": id, "audit.deleted": !deleted}, update)
 	if err != nil {
 		return err
 	}
 	if res.MatchedCount == 0 {
-		return errors.New("user not found")
+		return fmt.Errorf("user %s: %w", id.Hex(), domain.ErrNotFound)
 	}
 	return nil
 }
 
-func (r *userRepo) Delete(ctx context.Context, id primitive.ObjectID, actorID primitive.ObjectID) error {
-	update := bson.M{
-		"$set": bson.M{
-			"audit.deleted":   true,
-			"audit.updatedAt": time.Now().UTC(),
-			"audit.updatedBy": actorID,
-		},
-		"$inc": bson.M{"audit.version": 1},
-	}
-	_, err := r.coll.UpdateByID(ctx, id, update)
-	return err
-}
-
 func (r *userRepo) Update(ctx context.Context, u *domain.User, actorID string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	u.Audit.UpdatedAt = time.Now().UTC()
 	u.Audit.UpdatedBy = actorID
 	u.Audit.Version++
@@ -150,7 +192,20 @@ func (r *userRepo) Update(ctx context.Context, u *domain.User, actorID string) e
 		return err
 	}
 	if res.MatchedCount == 0 {
-		return errors.New("user not found or version mismatch")
+		return r.explainMiss(ctx, u.ID, fmt.Errorf("user %s was modified concurrently: %w", u.ID.Hex(), domain.ErrVersionMismatch))
 	}
 	return nil
 }
+
+// explainMiss distinguishes a conditional update that matched nothing
+// because the user is gone from one whose precondition failed.
+func (r *userRepo) explainMiss(ctx context.Context, id primitive.ObjectID, preconditionErr error) error {
+	n, err := r.coll.CountDocuments(ctx, bson.M{"_id": id, "audit.deleted": false}, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("user %s: %w", id.Hex(), domain.ErrNotFound)
+	}
+	return preconditionErr
+}
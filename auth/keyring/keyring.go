@@ -0,0 +1,137 @@
+// Package keyring holds the service's RSA private keys during rotation. The
+// primary key signs tokens and is the one clients are told to encrypt with;
+// older keys keep decrypting and verifying until their grace period ends, so
+// clients that cached the previous public key keep working.
+package keyring
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Key is one RSA key in the ring.
+type Key struct {
+	ID      string
+	Private *rsa.PrivateKey
+	// RetiredAt is when the key stopped being primary, or nil for keys
+	// still in service. Retired keys are only used for decryption and
+	// verification, and only until RetiredAt plus the ring's grace period.
+	RetiredAt *time.Time
+}
+
+// Retired reports whether k has been retired as of now.
+func (k *Key) Retired(now time.Time) bool {
+	return k.RetiredAt != nil && !now.Before(*k.RetiredAt)
+}
+
+// Ring is an ordered set of keys. The first key not yet retired is primary.
+type Ring struct {
+	keys  []*Key
+	grace time.Duration
+	now   func() time.Time
+}
+
+// New builds a ring from keys in order of preference. Key IDs must be
+// unique, and at least one key must have no RetiredAt: a ring whose every
+// key is scheduled to retire would be left without a primary once the last
+// of those times passed.
+func New(keys []*Key, grace time.Duration) (*Ring, error) {
+	r := &Ring{keys: keys, grace: grace, now: time.Now}
+	seen := map[string]bool{}
+	for _, k := range keys {
+		if k.ID == "" {
+			return nil, errors.New("key ring entry has no key id")
+		}
+		if seen[k.ID] {
+			return nil, fmt.Errorf("duplicate key id %q", k.ID)
+		}
+		seen[k.ID] = true
+	}
+	if !r.hasPermanentKey() {
+		return nil, errors.New("key ring needs a key that is not retired or scheduled to retire")
+	}
+	return r, nil
+}
+
+// Single wraps one key, identified by its RFC 7638 thumbprint.
+func Single(key *rsa.PrivateKey) *Ring {
+	r, _ := New([]*Key{{ID: Thumbprint(&key.PublicKey), Private: key}}, 0)
+	return r
+}
+
+// Primary returns the key that signs and that clients should encrypt to. It
+// is never nil for a ring built by New, which insists on a key that never
+// retires.
+func (r *Ring) Primary() *Key {
+	now := r.now()
+	for _, k := range r.keys {
+		if !k.Retired(now) {
+			return k
+		}
+	}
+	return nil
+}
+
+func (r *Ring) hasPermanentKey() bool {
+	for _, k := range r.keys {
+		if k.RetiredAt == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the usable key with id.
+func (r *Ring) Lookup(id string) (*Key, bool) {
+	for _, k := range r.Usable() {
+		if k.ID == id {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+// Usable returns the keys in service plus the retired keys still within
+// their grace period, primary first.
+func (r *Ring) Usable() []*Key {
+	now := r.now()
+	var keys []*Key
+	for _, k := range r.keys {
+		if k.RetiredAt == nil || now.Before(r.ExpiresAt(k)) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// ExpiresAt is when a retired key leaves the ring, or the zero time for keys
+// still in service.
+func (r *Ring) ExpiresAt(k *Key) time.Time {
+	if k.RetiredAt == nil {
+		return time.Time{}
+	}
+	return k.RetiredAt.Add(r.grace)
+}
+
+// Thumbprint derives a stable key ID from pub using its RFC 7638 thumbprint,
+// so any holder of the key computes the same ID.
+func Thumbprint(pub *rsa.PublicKey) string {
+	// Members in lexicographic order with no whitespace, as RFC 7638 requires.
+	canonical, _ := json.Marshal(struct {
+		E   string `json:"e"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+	}{
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		Kty: "RSA",
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+	})
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
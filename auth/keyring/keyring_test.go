@@ -0,0 +1,120 @@
+package keyring
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	testKeyOnce sync.Once
+	testKey     *rsa.PrivateKey
+)
+
+// key returns a Key with id. Every key shares one RSA key, since the ring
+// only looks at IDs and retirement times.
+func key(t *testing.T, id string, retiredAt *time.Time) *Key {
+	t.Helper()
+	testKeyOnce.Do(func() {
+		k, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			panic(err)
+		}
+		testKey = k
+	})
+	return &Key{ID: id, Private: testKey, RetiredAt: retiredAt}
+}
+
+func ids(keys []*Key) string {
+	var s []string
+	for _, k := range keys {
+		s = append(s, k.ID)
+	}
+	return strings.Join(s, ",")
+}
+
+func TestRingPrimaryAndUsable(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time { t := now.Add(d); return &t }
+
+	r, err := New([]*Key{
+		key(t, "scheduled", at(time.Hour)),
+		key(t, "current", nil),
+		key(t, "recent", at(-time.Hour)),
+		key(t, "expired", at(-48*time.Hour)),
+	}, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.now = func() time.Time { return now }
+
+	if got := r.Primary().ID; got != "scheduled" {
+		t.Errorf("primary = %s, want scheduled", got)
+	}
+	if got := ids(r.Usable()); got != "scheduled,current,recent" {
+		t.Errorf("usable = %s", got)
+	}
+	if _, ok := r.Lookup("expired"); ok {
+		t.Error("key past its grace period is still usable")
+	}
+
+	// Once the scheduled key retires, the permanent key takes over.
+	now = now.Add(2 * time.Hour)
+	if got := r.Primary().ID; got != "current" {
+		t.Errorf("primary after scheduled retirement = %s, want current", got)
+	}
+	// Long after every scheduled retirement there is still a primary.
+	now = now.Add(365 * 24 * time.Hour)
+	if p := r.Primary(); p == nil || p.ID != "current" {
+		t.Errorf("primary a year later = %v, want current", p)
+	}
+}
+
+func TestNewRejects(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name string
+		keys []*Key
+		want string
+	}{
+		{"empty ring", nil, "not retired or scheduled to retire"},
+		{"only retired keys", []*Key{key(t, "a", &past)}, "not retired or scheduled to retire"},
+		{"only scheduled keys", []*Key{key(t, "a", &future)}, "not retired or scheduled to retire"},
+		{"missing kid", []*Key{key(t, "", nil)}, "no key id"},
+		{"duplicate kid", []*Key{key(t, "a", nil), key(t, "a", &past)}, "duplicate key id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.keys, time.Hour)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSingle(t *testing.T) {
+	k := key(t, "", nil)
+	r := Single(k.Private)
+	if got, want := r.Primary().ID, Thumbprint(&k.Private.PublicKey); got != want {
+		t.Errorf("kid = %s, want thumbprint %s", got, want)
+	}
+}
+
+// The RFC 7638 section 3.1 example key and its thumbprint.
+func TestThumbprint(t *testing.T) {
+	n, err := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Thumbprint(&rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537})
+	if want := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"; got != want {
+		t.Errorf("thumbprint = %s, want %s", got, want)
+	}
+}
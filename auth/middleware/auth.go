@@ -0,0 +1,266 @@
+// Package middleware holds the Gin middleware of the auth service. Its token
+// verification lets other Gin services trust access tokens issued by the
+// auth service: it depends only on the domain types and problem responses,
+// not on the auth service's configuration or keys, so those services can
+// import it directly.
+package middleware
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"github.com/rupesh-sengar/golang-collection/auth/problem"
+)
+
+const principalKey = "auth.principal"
+
+var (
+	ErrMissingToken = errors.New("missing bearer token")
+	ErrRevoked      = errors.New("token has been revoked")
+)
+
+// Principal is the authenticated caller behind a verified access token.
+type Principal struct {
+	UserID      string
+	Email       domain.Email
+	Roles       []domain.UserRole
+	Application string
+	Claims      *Claims
+}
+
+func (p *Principal) HasRole(role domain.UserRole) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type Config struct {
+	Issuer   string
+	Audience string
+
+	// Exactly one key source is used, in this order of precedence.
+	KeyFunc   func(kid string) (*rsa.PublicKey, error)
+	PublicKey *rsa.PublicKey
+	JWKSURL   string
+
+	JWKSCacheTTL time.Duration
+	ClockSkew    time.Duration
+	HTTPClient   *http.Client
+
+	// IsRevoked, when set, rejects tokens whose jti it reports as revoked.
+	IsRevoked func(ctx context.Context, jti string) (bool, error)
+}
+
+// ConfigFromEnv reads AUTH_ISSUER, AUTH_AUDIENCE, AUTH_JWKS_URL,
+// AUTH_JWKS_CACHE_TTL and AUTH_CLOCK_SKEW.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Issuer:   os.Getenv("AUTH_ISSUER"),
+		Audience: os.Getenv("AUTH_AUDIENCE"),
+		JWKSURL:  os.Getenv("AUTH_JWKS_URL"),
+	}
+	for name, dst := range map[string]*time.Duration{
+		"AUTH_JWKS_CACHE_TTL": &cfg.JWKSCacheTTL,
+		"AUTH_CLOCK_SKEW":     &cfg.ClockSkew,
+	} {
+		if raw := os.Getenv(name); raw != "" {
+			d, err := time.ParseDuration(raw)
+			if err != nil {
+				return cfg, fmt.Errorf("invalid %s %q", name, raw)
+			}
+			*dst = d
+		}
+	}
+	if cfg.Issuer == "" || cfg.JWKSURL == "" {
+		return cfg, errors.New("AUTH_ISSUER and AUTH_JWKS_URL must be set")
+	}
+	return cfg, nil
+}
+
+// Verifier checks access tokens against a Config.
+type Verifier struct {
+	cfg  Config
+	jwks *jwksCache
+}
+
+func NewVerifier(cfg Config) *Verifier {
+	if cfg.JWKSCacheTTL == 0 {
+		cfg.JWKSCacheTTL = 10 * time.Minute
+	}
+	if cfg.ClockSkew == 0 {
+		cfg.ClockSkew = 30 * time.Second
+	}
+	v := &Verifier{cfg: cfg}
+	if cfg.KeyFunc == nil && cfg.PublicKey == nil && cfg.JWKSURL != "" {
+		v.jwks = newJWKSCache(cfg.JWKSURL, cfg.JWKSCacheTTL, cfg.HTTPClient)
+	}
+	return v
+}
+
+// Verify checks raw's signature, issuer, audience and lifetime and returns
+// the principal it was issued to.
+func (v *Verifier) Verify(ctx context.Context, raw string) (*Principal, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithLeeway(v.cfg.ClockSkew),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if v.cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.cfg.Issuer))
+	}
+	if v.cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(v.cfg.Audience))
+	}
+
+	var claims Claims
+	_, err := jwt.ParseWithClaims(raw, &claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.key(ctx, kid)
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenUse != UseAccess {
+		return nil, errors.New("not an access token")
+	}
+
+	if v.cfg.IsRevoked != nil && claims.ID != "" {
+		revoked, err := v.cfg.IsRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, ErrRevoked
+		}
+	}
+
+	return &Principal{
+		UserID:      claims.Subject,
+		Email:       claims.Email,
+		Roles:       claims.Roles,
+		Application: claims.Application,
+		Claims:      &claims,
+	}, nil
+}
+
+func (v *Verifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	switch {
+	case v.cfg.KeyFunc != nil:
+		return v.cfg.KeyFunc(kid)
+	case v.cfg.PublicKey != nil:
+		return v.cfg.PublicKey, nil
+	case v.jwks != nil:
+		return v.jwks.key(ctx, kid)
+	default:
+		return nil, errors.New("no verification key configured")
+	}
+}
+
+// RequireAuth aborts with 401 unless the request carries a valid bearer
+// token, and otherwise stores the caller's Principal in the context.
+func (v *Verifier) RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := BearerToken(c)
+		if raw == "" {
+			unauthorized(c, ErrMissingToken)
+			return
+		}
+		p, err := v.Verify(c.Request.Context(), raw)
+		if err != nil {
+			unauthorized(c, err)
+			return
+		}
+		c.Set(principalKey, p)
+		c.Next()
+	}
+}
+
+// OptionalAuth stores the caller's Principal when a bearer token is present
+// and lets anonymous requests through. A token that is present but invalid
+// is still rejected, so a bad token never silently downgrades to anonymous.
+func (v *Verifier) OptionalAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := BearerToken(c)
+		if raw == "" {
+			c.Next()
+			return
+		}
+		p, err := v.Verify(c.Request.Context(), raw)
+		if err != nil {
+			unauthorized(c, err)
+			return
+		}
+		c.Set(principalKey, p)
+		c.Next()
+	}
+}
+
+var (
+	defaultMu       sync.Mutex
+	defaultVerifier *Verifier
+)
+
+// Configure sets the verifier used by the package-level RequireAuth.
+func Configure(cfg Config) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultVerifier = NewVerifier(cfg)
+}
+
+// RequireAuth uses the verifier installed by Configure, or one built from
+// ConfigFromEnv on first use. Without a configuration it logs why and fails
+// every request with 500 rather than let callers through; services that
+// want to fail at startup instead call ConfigFromEnv and Configure first.
+func RequireAuth() gin.HandlerFunc {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultVerifier == nil {
+		cfg, err := ConfigFromEnv()
+		if err != nil {
+			log.Printf("RequireAuth is not configured: %v", err)
+			return func(c *gin.Context) {
+				problem.Write(c, fmt.Errorf("auth middleware is not configured: %w", err))
+			}
+		}
+		defaultVerifier = NewVerifier(cfg)
+	}
+	return defaultVerifier.RequireAuth()
+}
+
+// GetPrincipal returns the caller stored by RequireAuth.
+func GetPrincipal(c *gin.Context) (*Principal, bool) {
+	v, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	p, ok := v.(*Principal)
+	return p, ok
+}
+
+func BearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+func unauthorized(c *gin.Context, err error) {
+	c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+	problem.Write(c, fmt.Errorf("%w: %v", domain.ErrUnauthorized, err))
+}
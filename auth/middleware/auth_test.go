@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+)
+
+const (
+	testIssuer   = "https://auth.example.com"
+	testAudience = "api"
+)
+
+// testToken signs claims as the auth service would, letting modify adjust
+// them first.
+func testToken(t *testing.T, key *rsa.PrivateKey, kid string, modify func(*Claims)) string {
+	t.Helper()
+	now := time.Now()
+	claims := Claims{
+		TokenUse:    UseAccess,
+		Email:       "a@example.com",
+		Roles:       []domain.UserRole{domain.RoleMember},
+		Application: "shop",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "user-1",
+			Issuer:    testIssuer,
+			Audience:  jwt.ClaimStrings{testAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			ID:        "jti-1",
+		},
+	}
+	if modify != nil {
+		modify(&claims)
+	}
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = kid
+	raw, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestVerifierVerify(t *testing.T) {
+	v := NewVerifier(Config{
+		Issuer:    testIssuer,
+		Audience:  testAudience,
+		PublicKey: &testKey(0).PublicKey,
+		ClockSkew: 30 * time.Second,
+		IsRevoked: func(ctx context.Context, jti string) (bool, error) { return jti == "revoked", nil },
+	})
+
+	tests := []struct {
+		name   string
+		key    *rsa.PrivateKey
+		modify func(*Claims)
+		ok     bool
+	}{
+		{name: "valid", key: testKey(0), ok: true},
+		{name: "expired within clock skew", key: testKey(0), modify: func(c *Claims) {
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-10 * time.Second))
+		}, ok: true},
+		{name: "expired beyond clock skew", key: testKey(0), modify: func(c *Claims) {
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+		}},
+		{name: "no expiry", key: testKey(0), modify: func(c *Claims) { c.ExpiresAt = nil }},
+		{name: "other issuer", key: testKey(0), modify: func(c *Claims) { c.Issuer = "https://evil.example.com" }},
+		{name: "other audience", key: testKey(0), modify: func(c *Claims) { c.Audience = jwt.ClaimStrings{"shop"} }},
+		{name: "ID token", key: testKey(0), modify: func(c *Claims) { c.TokenUse = UseID }},
+		{name: "revoked", key: testKey(0), modify: func(c *Claims) { c.ID = "revoked" }},
+		{name: "signed by another key", key: testKey(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := v.Verify(context.Background(), testToken(t, tt.key, "", tt.modify))
+			if !tt.ok {
+				if err == nil {
+					t.Error("token accepted")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("token rejected: %v", err)
+			}
+			if p.UserID != "user-1" || p.Email != "a@example.com" || p.Application != "shop" || !p.HasRole(domain.RoleMember) {
+				t.Errorf("principal = %+v", p)
+			}
+		})
+	}
+}
+
+func TestVerifierRejectsHMAC(t *testing.T) {
+	v := NewVerifier(Config{PublicKey: &testKey(0).PublicKey})
+	tok := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	})
+	raw, err := tok.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Verify(context.Background(), raw); err == nil {
+		t.Error("HS256 token accepted")
+	}
+}
+
+func TestVerifierJWKS(t *testing.T) {
+	srv := newJWKSServer(t, map[string]*rsa.PublicKey{"k0": &testKey(0).PublicKey})
+	v := NewVerifier(Config{Issuer: testIssuer, Audience: testAudience, JWKSURL: srv.URL})
+
+	if _, err := v.Verify(context.Background(), testToken(t, testKey(0), "k0", nil)); err != nil {
+		t.Errorf("token with a published kid rejected: %v", err)
+	}
+	if _, err := v.Verify(context.Background(), testToken(t, testKey(1), "k0", nil)); err == nil {
+		t.Error("token signed by another key under a published kid accepted")
+	}
+	if _, err := v.Verify(context.Background(), testToken(t, testKey(1), "k1", nil)); err == nil {
+		t.Error("token with an unknown kid accepted")
+	}
+}
+
+func TestRequireAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	v := NewVerifier(Config{Issuer: testIssuer, Audience: testAudience, PublicKey: &testKey(0).PublicKey})
+	r := gin.New()
+	r.GET("/me", v.RequireAuth(), func(c *gin.Context) {
+		p, ok := GetPrincipal(c)
+		if !ok {
+			t.Error("no principal in the context")
+			return
+		}
+		c.String(http.StatusOK, p.UserID)
+	})
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"not a bearer token", "Basic dXNlcjpwYXNz", http.StatusUnauthorized},
+		{"invalid token", "Bearer not-a-jwt", http.StatusUnauthorized},
+		{"valid token", "Bearer " + testToken(t, testKey(0), "", nil), http.StatusOK},
+		{"lower-case scheme", "bearer " + testToken(t, testKey(0), "", nil), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without WWW-Authenticate")
+			}
+			if tt.wantStatus == http.StatusOK && w.Body.String() != "user-1" {
+				t.Errorf("principal user = %q", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireAuthUnconfigured(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("AUTH_ISSUER", "")
+	t.Setenv("AUTH_JWKS_URL", "")
+	defaultMu.Lock()
+	saved := defaultVerifier
+	defaultVerifier = nil
+	defaultMu.Unlock()
+	t.Cleanup(func() {
+		defaultMu.Lock()
+		defaultVerifier = saved
+		defaultMu.Unlock()
+	})
+
+	r := gin.New()
+	r.GET("/me", RequireAuth(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+testToken(t, testKey(0), "", nil))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500 when RequireAuth has no configuration", w.Code)
+	}
+}
+
+func TestVerifierReportsRevocation(t *testing.T) {
+	v := NewVerifier(Config{
+		PublicKey: &testKey(0).PublicKey,
+		IsRevoked: func(ctx context.Context, jti string) (bool, error) { return true, nil },
+	})
+	if _, err := v.Verify(context.Background(), testToken(t, testKey(0), "", nil)); !errors.Is(err, ErrRevoked) {
+		t.Errorf("err = %v, want ErrRevoked", err)
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"github.com/rupesh-sengar/golang-collection/auth/problem"
+)
+
+// RequireRole admits callers holding at least one of roles. It must run after
+// RequireAuth. Denials are answered with 403 and written to the audit log.
+func RequireRole(roles ...domain.UserRole) gin.HandlerFunc {
+	required := make([]string, len(roles))
+	for i, r := range roles {
+		required[i] = string(r)
+	}
+
+	return func(c *gin.Context) {
+		p, ok := GetPrincipal(c)
+		if !ok {
+			unauthorized(c, ErrMissingToken)
+			return
+		}
+		for _, role := range roles {
+			if p.HasRole(role) {
+				c.Next()
+				return
+			}
+		}
+
+		log.Printf("audit: authorization denied user=%s application=%s roles=%v required=%s method=%s path=%s ip=%s",
+			p.UserID, p.Application, p.Roles, strings.Join(required, "|"),
+			c.Request.Method, c.FullPath(), c.ClientIP())
+		problem.Write(c, fmt.Errorf("%w: requires role %s", domain.ErrForbidden, strings.Join(required, " or ")))
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+)
+
+// Token uses, carried in the token_use claim.
+const (
+	UseAccess = "access"
+	UseID     = "id"
+)
+
+// Claims is the payload of every token the auth service signs.
+type Claims struct {
+	TokenUse    string            `json:"token_use"`
+	Scope       string            `json:"scope,omitempty"`
+	Email       domain.Email      `json:"email,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	Roles       []domain.UserRole `json:"roles,omitempty"`
+	Application string            `json:"application,omitempty"`
+	Status      domain.UserStatus `json:"status,omitempty"`
+	jwt.RegisteredClaims
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"github.com/rupesh-sengar/golang-collection/auth/keyring"
+	"github.com/rupesh-sengar/golang-collection/auth/problem"
+	"github.com/rupesh-sengar/golang-collection/auth/utils"
+)
+
+// maxEncryptedBody bounds how much of an encrypted body is read.
+const maxEncryptedBody = 1 << 20
+
+// DecryptBody lets clients send any JSON body as a utils.HybridEnvelope with
+// Content-Type application/encrypted+json. The envelope is opened with keys
+// and replaced by the plaintext JSON, so handlers bind it as usual. Other
+// requests pass through untouched.
+//
+// The associated data is "<METHOD> <path>", e.g. "POST /api/v1/signup", so a
+// body encrypted for one endpoint is rejected by every other.
+func DecryptBody(keys *keyring.Ring) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		if mediaType != utils.EncryptedBodyContentType {
+			c.Next()
+			return
+		}
+
+		var env utils.HybridEnvelope
+		body := http.MaxBytesReader(c.Writer, c.Request.Body, maxEncryptedBody)
+		if err := json.NewDecoder(body).Decode(&env); err != nil {
+			problem.Write(c, domain.NewValidationError("body", "malformed encrypted body"))
+			return
+		}
+		aad := []byte(c.Request.Method + " " + c.Request.URL.Path)
+		plaintext, err := utils.OpenHybrid(keys, &env, aad)
+		if err != nil {
+			fmt.Println("Body decryption failed:", err)
+			problem.Write(c, domain.NewValidationError("body", "could not be decrypted"))
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(plaintext))
+		c.Request.ContentLength = int64(len(plaintext))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Next()
+	}
+}
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// minJWKSRefresh limits how often the set is refetched, successfully or
+// not, so neither a stream of forged kids nor an auth service outage turns
+// every request into a fetch.
+const minJWKSRefresh = 30 * time.Second
+
+// jwksFetchTimeout bounds a fetch, which runs on behalf of every caller
+// waiting for it rather than the one that started it.
+const jwksFetchTimeout = 10 * time.Second
+
+type jwksCache struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	fetchedAt   time.Time // last successful fetch
+	attemptedAt time.Time // last fetch, successful or not
+	inflight    *jwksFetch
+}
+
+// jwksFetch is a fetch in progress. done is closed once err is set and, on
+// success, the cache holds the new keys.
+type jwksFetch struct {
+	done chan struct{}
+	err  error
+}
+
+func newJWKSCache(url string, ttl time.Duration, client *http.Client) *jwksCache {
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &jwksCache{url: url, ttl: ttl, client: client}
+}
+
+// key returns the public key for kid, refetching the set when it is stale or
+// does not contain kid. Concurrent callers share one fetch, and the lock is
+// never held while it runs.
+func (c *jwksCache) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	c.mu.Lock()
+	k, ok := c.keys[kid]
+	if ok && time.Since(c.fetchedAt) < c.ttl {
+		c.mu.Unlock()
+		return k, nil
+	}
+	f := c.inflight
+	if f == nil && time.Since(c.attemptedAt) >= minJWKSRefresh {
+		f = c.startFetch()
+	}
+	c.mu.Unlock()
+
+	if f != nil {
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if k, ok := c.keys[kid]; ok {
+		// After a failed fetch this may be a stale key; serve it rather
+		// than fail closed on a blip.
+		return k, nil
+	}
+	if f != nil && f.err != nil {
+		return nil, f.err
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// startFetch records the attempt and fetches the set in the background.
+// c.mu must be held.
+func (c *jwksCache) startFetch() *jwksFetch {
+	f := &jwksFetch{done: make(chan struct{})}
+	c.inflight = f
+	c.attemptedAt = time.Now()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+		defer cancel()
+		keys, err := c.fetch(ctx)
+
+		c.mu.Lock()
+		if err == nil {
+			c.keys = keys
+			c.fetchedAt = time.Now()
+		}
+		f.err = err
+		c.inflight = nil
+		c.mu.Unlock()
+		close(f.done)
+	}()
+	return f
+}
+
+func (c *jwksCache) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks fetch failed with status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		pub, err := rsaPublicKey(k.N, k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks contains no usable RSA signing keys")
+	}
+	return keys, nil
+}
+
+func rsaPublicKey(n, e string) (*rsa.PublicKey, error) {
+	nb, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, err
+	}
+	eb, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, err
+	}
+	exp := new(big.Int).SetBytes(eb)
+	if !exp.IsInt64() || exp.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid RSA exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(nb), E: int(exp.Int64())}, nil
+}
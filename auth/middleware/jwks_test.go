@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var (
+	testKeysOnce sync.Once
+	testKeys     [2]*rsa.PrivateKey
+)
+
+func testKey(i int) *rsa.PrivateKey {
+	testKeysOnce.Do(func() {
+		for i := range testKeys {
+			k, err := rsa.GenerateKey(rand.Reader, 2048)
+			if err != nil {
+				panic(err)
+			}
+			testKeys[i] = k
+		}
+	})
+	return testKeys[i]
+}
+
+// jwksServer serves a JWKS and counts the fetches. While failing is set it
+// answers 500; while gate is set, each fetch waits for it to close.
+type jwksServer struct {
+	*httptest.Server
+	fetches atomic.Int32
+
+	mu      sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	failing bool
+	gate    chan struct{}
+}
+
+func newJWKSServer(t *testing.T, keys map[string]*rsa.PublicKey) *jwksServer {
+	s := &jwksServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		s.mu.Lock()
+		keys, failing, gate := s.keys, s.failing, s.gate
+		s.mu.Unlock()
+		if gate != nil {
+			<-gate
+		}
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		type jwk struct {
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		}
+		var set struct {
+			Keys []jwk `json:"keys"`
+		}
+		for kid, pub := range keys {
+			set.Keys = append(set.Keys, jwk{
+				Kty: "RSA",
+				Use: "sig",
+				Kid: kid,
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) set(keys map[string]*rsa.PublicKey, failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys, s.failing = keys, failing
+}
+
+func TestJWKSCacheCachesKeys(t *testing.T) {
+	srv := newJWKSServer(t, map[string]*rsa.PublicKey{"a": &testKey(0).PublicKey})
+	c := newJWKSCache(srv.URL, time.Hour, nil)
+
+	for i := 0; i < 3; i++ {
+		k, err := c.key(context.Background(), "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !k.Equal(&testKey(0).PublicKey) {
+			t.Fatal("wrong key for kid a")
+		}
+	}
+	if n := srv.fetches.Load(); n != 1 {
+		t.Errorf("fetched %d times, want 1", n)
+	}
+}
+
+func TestJWKSCacheUnknownKidBacksOff(t *testing.T) {
+	srv := newJWKSServer(t, map[string]*rsa.PublicKey{"a": &testKey(0).PublicKey})
+	c := newJWKSCache(srv.URL, time.Hour, nil)
+	ctx := context.Background()
+	if _, err := c.key(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A kid the set lacks does not refetch within minJWKSRefresh.
+	srv.set(map[string]*rsa.PublicKey{"a": &testKey(0).PublicKey, "b": &testKey(1).PublicKey}, false)
+	if _, err := c.key(ctx, "b"); err == nil {
+		t.Error("unknown kid accepted before the set was refetched")
+	}
+	if n := srv.fetches.Load(); n != 1 {
+		t.Errorf("fetched %d times within minJWKSRefresh, want 1", n)
+	}
+
+	// Once it has passed, the unknown kid triggers a refetch that finds it.
+	c.attemptedAt = time.Now().Add(-minJWKSRefresh)
+	if _, err := c.key(ctx, "b"); err != nil {
+		t.Errorf("rotated-in kid: %v", err)
+	}
+	if n := srv.fetches.Load(); n != 2 {
+		t.Errorf("fetched %d times, want 2", n)
+	}
+}
+
+func TestJWKSCacheFailedRefresh(t *testing.T) {
+	srv := newJWKSServer(t, map[string]*rsa.PublicKey{"a": &testKey(0).PublicKey})
+	c := newJWKSCache(srv.URL, time.Minute, nil)
+	ctx := context.Background()
+	if _, err := c.key(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed refresh of a stale set keeps serving the stale key and
+	// counts as an attempt, so the next call does not refetch.
+	srv.set(nil, true)
+	c.fetchedAt = time.Now().Add(-time.Hour)
+	c.attemptedAt = c.fetchedAt
+	for i := 0; i < 2; i++ {
+		if _, err := c.key(ctx, "a"); err != nil {
+			t.Fatalf("stale key not served: %v", err)
+		}
+	}
+	if n := srv.fetches.Load(); n != 2 {
+		t.Errorf("fetched %d times, want 2", n)
+	}
+	if _, err := c.key(ctx, "b"); err == nil {
+		t.Error("unknown kid accepted while the JWKS is failing")
+	}
+	if n := srv.fetches.Load(); n != 2 {
+		t.Errorf("fetched %d times after a failed attempt, want 2", n)
+	}
+}
+
+func TestJWKSCacheFirstFetchFails(t *testing.T) {
+	srv := newJWKSServer(t, nil)
+	srv.set(nil, true)
+	c := newJWKSCache(srv.URL, time.Hour, nil)
+
+	if _, err := c.key(context.Background(), "a"); err == nil {
+		t.Fatal("key found without a JWKS")
+	}
+	if _, err := c.key(context.Background(), "a"); err == nil {
+		t.Fatal("key found without a JWKS")
+	}
+	if n := srv.fetches.Load(); n != 1 {
+		t.Errorf("fetched %d times, want 1 within minJWKSRefresh", n)
+	}
+}
+
+func TestJWKSCacheCoalescesFetches(t *testing.T) {
+	srv := newJWKSServer(t, map[string]*rsa.PublicKey{"a": &testKey(0).PublicKey})
+	gate := make(chan struct{})
+	srv.mu.Lock()
+	srv.gate = gate
+	srv.mu.Unlock()
+	c := newJWKSCache(srv.URL, time.Hour, nil)
+
+	const callers = 8
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			_, err := c.key(context.Background(), "a")
+			errs <- err
+		}()
+	}
+
+	// Other lookups are not blocked behind the fetch.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.key(ctx, "a"); err != context.DeadlineExceeded {
+		t.Errorf("lookup during the fetch: err = %v, want it to give up with its context", err)
+	}
+
+	close(gate)
+	for i := 0; i < callers; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+	if n := srv.fetches.Load(); n != 1 {
+		t.Errorf("fetched %d times for %d concurrent callers, want 1", n, callers)
+	}
+}
@@ -0,0 +1,111 @@
+// Package problem renders errors as RFC 7807 problem documents. Handlers
+// record errors with c.Error and the Errors middleware answers for them.
+package problem
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem document. Code is a stable, machine-readable
+// identifier clients can switch on; Title and Detail are for humans and may
+// change.
+type Problem struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Detail   string              `json:"detail,omitempty"`
+	Instance string              `json:"instance,omitempty"`
+	Code     string              `json:"code"`
+	Errors   []domain.FieldError `json:"errors,omitempty"`
+}
+
+// Stable problem codes.
+const (
+	CodeValidationFailed  = "validation_failed"
+	CodeNotFound          = "not_found"
+	CodeConflict          = "conflict"
+	CodeVersionMismatch   = "version_mismatch"
+	CodeInvalidTransition = "invalid_transition"
+	CodeUnauthorized      = "unauthorized"
+	CodeForbidden         = "forbidden"
+	CodeInternal          = "internal_error"
+)
+
+// problemKinds maps the domain sentinels onto problem documents. The detail
+// is fixed per code: the wrapped error can carry upstream response bodies or
+// other users' emails, so it is only ever logged.
+var problemKinds = []struct {
+	err    error
+	status int
+	code   string
+	detail string
+}{
+	{domain.ErrNotFound, http.StatusNotFound, CodeNotFound, "The requested resource was not found."},
+	{domain.ErrConflict, http.StatusConflict, CodeConflict, "The resource already exists."},
+	{domain.ErrVersionMismatch, http.StatusConflict, CodeVersionMismatch, "The resource was changed by another request; reload it and try again."},
+	{domain.ErrInvalidTransition, http.StatusConflict, CodeInvalidTransition, "The user's current status does not allow this change."},
+	{domain.ErrUnauthorized, http.StatusUnauthorized, CodeUnauthorized, "Authentication is required or has failed."},
+	{domain.ErrForbidden, http.StatusForbidden, CodeForbidden, "You are not allowed to perform this action."},
+}
+
+// For maps err onto a problem document. Only validation errors contribute
+// their own text, the field messages; every other error gets the fixed
+// detail of its code, and errors that wrap none of the domain sentinels are
+// reported as 500.
+func For(err error) Problem {
+	var verr *domain.ValidationError
+	if errors.As(err, &verr) {
+		return newProblem(http.StatusBadRequest, CodeValidationFailed, "One or more fields are invalid.", verr.Fields)
+	}
+	for _, k := range problemKinds {
+		if errors.Is(err, k.err) {
+			return newProblem(k.status, k.code, k.detail, nil)
+		}
+	}
+	return newProblem(http.StatusInternalServerError, CodeInternal, "An unexpected error occurred.", nil)
+}
+
+func newProblem(status int, code, detail string, fields []domain.FieldError) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+		Errors: fields,
+	}
+}
+
+// Errors renders the last error a handler recorded with c.Error as a problem
+// response, so handlers only need to record the error and return.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		Write(c, c.Errors.Last().Err)
+	}
+}
+
+// Write aborts c with the problem document for err. The full error is
+// logged, since the response no longer carries it.
+func Write(c *gin.Context, err error) {
+	p := For(err)
+	switch {
+	case p.Status == http.StatusInternalServerError:
+		log.Printf("%s %s: unhandled error: %v", c.Request.Method, c.Request.URL.Path, err)
+	case p.Code != CodeValidationFailed:
+		log.Printf("%s %s: %d %s: %v", c.Request.Method, c.Request.URL.Path, p.Status, p.Code, err)
+	}
+	p.Instance = c.Request.URL.Path
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
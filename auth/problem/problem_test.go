@@ -0,0 +1,90 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+)
+
+func TestFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"not found", fmt.Errorf("user 42: %w", domain.ErrNotFound), http.StatusNotFound, CodeNotFound},
+		{"conflict", fmt.Errorf("auth0 signup failed: {\"email\":\"a@example.com\"}: %w", domain.ErrConflict), http.StatusConflict, CodeConflict},
+		{"version mismatch", domain.ErrVersionMismatch, http.StatusConflict, CodeVersionMismatch},
+		{"invalid transition", fmt.Errorf("cannot move user from active to approved: %w", domain.ErrInvalidTransition), http.StatusConflict, CodeInvalidTransition},
+		{"unauthorized", fmt.Errorf("%w: token expired", domain.ErrUnauthorized), http.StatusUnauthorized, CodeUnauthorized},
+		{"forbidden", fmt.Errorf("%w: requires role admin", domain.ErrForbidden), http.StatusForbidden, CodeForbidden},
+		{"unknown", errors.New("mongo: connection refused to 10.0.0.5"), http.StatusInternalServerError, CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := For(tt.err)
+			if p.Status != tt.status || p.Code != tt.code {
+				t.Errorf("For = %d %s, want %d %s", p.Status, p.Code, tt.status, tt.code)
+			}
+			if p.Title != http.StatusText(tt.status) || p.Type != "about:blank" {
+				t.Errorf("title %q, type %q", p.Title, p.Type)
+			}
+			// The detail is fixed per code and never carries the error's
+			// own text, which may hold upstream bodies or emails.
+			if p.Detail == "" || strings.Contains(tt.err.Error(), p.Detail) {
+				t.Errorf("detail %q leaks or is missing", p.Detail)
+			}
+		})
+	}
+}
+
+func TestForValidationError(t *testing.T) {
+	err := fmt.Errorf("signup: %w", domain.NewValidationError("email", "must be a valid email address"))
+	p := For(err)
+	if p.Status != http.StatusBadRequest || p.Code != CodeValidationFailed {
+		t.Fatalf("For = %d %s, want 400 %s", p.Status, p.Code, CodeValidationFailed)
+	}
+	if len(p.Errors) != 1 || p.Errors[0].Field != "email" {
+		t.Errorf("errors = %+v, want the email field", p.Errors)
+	}
+}
+
+func TestErrorsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Errors())
+	r.GET("/users/:id", func(c *gin.Context) {
+		c.Error(fmt.Errorf("user %s: %w", c.Param("id"), domain.ErrNotFound))
+	})
+	r.GET("/ok", func(c *gin.Context) { c.String(http.StatusOK, "fine") })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, problemContentType) {
+		t.Errorf("content type = %q", ct)
+	}
+	var p Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Code != CodeNotFound || p.Instance != "/users/42" {
+		t.Errorf("problem = %+v", p)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "fine" {
+		t.Errorf("handler without errors answered %d %q", w.Code, w.Body.String())
+	}
+}
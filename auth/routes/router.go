@@ -2,19 +2,44 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
-	"github.com/rupesh-sengar/golang-collection/auth/controllers"
+	"github.com/rupesh-sengar/golang-collection/auth/app"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"github.com/rupesh-sengar/golang-collection/auth/middleware"
+	"github.com/rupesh-sengar/golang-collection/auth/problem"
 )
 
-func RegisterRoutes(r *gin.Engine) {
-	api := r.Group("/api/v1")
+func RegisterRoutes(r *gin.Engine, container *app.Container) {
+	ctl := container.Controller
+	cfg := container.Config
+	verifier := middleware.NewVerifier(middleware.Config{
+		Issuer:    cfg.Tokens.Issuer,
+		Audience:  cfg.Tokens.Audience,
+		KeyFunc:   container.Signer.VerificationKey,
+		IsRevoked: container.Tokens.IsTokenRevoked,
+	})
+	r.Use(middleware.Deadline(cfg.Timeouts.Request), problem.Errors())
+
+	requireAuth := verifier.RequireAuth()
+	optionalAuth := verifier.OptionalAuth()
+
+	wellKnown := r.Group("/.well-known")
 	{
-		api.POST("/login", controllers.LoginHandler)
+		wellKnown.GET("/jwks.json", ctl.JWKSHandler)
+		wellKnown.GET("/openid-configuration", ctl.OpenIDConfigurationHandler)
+	}
+
+	api := r.Group("/api/v1", middleware.DecryptBody(cfg.Keys.Ring))
+	{
+		api.POST("/login", ctl.LoginHandler)
+		api.GET("/encryption-key", ctl.EncryptionKeyHandler)
 		api.GET("/health", func(c *gin.Context) {
 			c.JSON(200, gin.H{"status": "ok"})
 		})
-		api.POST("/signup", controllers.SignupHandler)
-		api.POST("/approve-user", controllers.AuthApprovalHandler)
+		api.POST("/signup", optionalAuth, ctl.SignupHandler)
+		api.POST("/approve-user", requireAuth, middleware.RequireRole(domain.RoleAdmin), ctl.AuthApprovalHandler)
+		api.POST("/token/refresh", ctl.RefreshHandler)
+		api.POST("/logout", requireAuth, ctl.LogoutHandler)
+		api.POST("/revoke", ctl.RevokeHandler)
+		api.POST("/introspect", ctl.IntrospectHandler)
 	}
 }
-
-
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/rupesh-sengar/golang-collection/auth/config"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// auth0UserIDKey is the User.Meta key holding the Auth0 user_id, which the
+// management API needs to block or unblock the account.
+const auth0UserIDKey = "auth0UserId"
+
+// Auth0Provider authenticates against an Auth0 database connection and
+// mirrors registrations into the local user repository.
+type Auth0Provider struct {
+	users     domain.UserRepository
+	passwords *domain.PasswordHasher
+	cfg       config.Auth0Config
+	timeout   time.Duration
+}
+
+// NewAuth0Provider builds a provider for the tenant in cfg. The local copy
+// of each password is hashed with passwords, and timeout bounds each
+// outbound call to Auth0.
+func NewAuth0Provider(users domain.UserRepository, passwords *domain.PasswordHasher, cfg config.Auth0Config, timeout time.Duration) *Auth0Provider {
+	return &Auth0Provider{users: users, passwords: passwords, cfg: cfg, timeout: timeout}
+}
+
+func (p *Auth0Provider) Name() string { return ProviderAuth0 }
+
+// auth0PasswordGrant is the body of a resource owner password grant.
+type auth0PasswordGrant struct {
+	GrantType    string `json:"grant_type"`
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	Audience     string `json:"audience"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	Scope        string `json:"scope"`
+}
+
+// auth0Signup is the body of a database connection signup.
+type auth0Signup struct {
+	ClientID     string            `json:"client_id"`
+	Email        string            `json:"email"`
+	Password     string            `json:"password"`
+	Connection   string            `json:"connection"`
+	UserMetadata auth0UserMetadata `json:"user_metadata"`
+}
+
+type auth0UserMetadata struct {
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	Role        string `json:"role"`
+	Application string `json:"application"`
+}
+
+func (p *Auth0Provider) Authenticate(ctx context.Context, creds Credentials) (*Identity, error) {
+	payload, err := json.Marshal(auth0PasswordGrant{
+		GrantType:    "password",
+		Username:     creds.Username,
+		Password:     creds.Password,
+		Audience:     p.cfg.Audience,
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		Scope:        "openid profile email",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	status, body, err := p.do(ctx, http.MethodPost,
+		"https://"+p.cfg.Domain+"/oauth/token",
+		bytes.NewReader(payload), "")
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		return nil, fmt.Errorf("Auth0 error: %s: %w", body, ErrInvalidCredentials)
+	}
+	if status != 200 {
+		return nil, fmt.Errorf("Auth0 error: %s", body)
+	}
+
+	var token Auth0TokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, err
+	}
+
+	// Auth0 accounts are shared across applications; only a local record
+	// in creds.Application lets the login into that application.
+	user, err := p.users.FindByEmail(ctx, domain.Email(creds.Username), creds.Application)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	upgradePasswordHash(ctx, p.users, p.passwords, user, creds.Password)
+
+	return &Identity{User: user, Token: &token}, nil
+}
+
+func (p *Auth0Provider) Register(ctx context.Context, reg Registration) (*domain.User, error) {
+	payload, err := json.Marshal(auth0Signup{
+		ClientID:   p.cfg.ClientID,
+		Email:      reg.Email,
+		Password:   reg.Password,
+		Connection: "Username-Password-Authentication",
+		UserMetadata: auth0UserMetadata{
+			FirstName:   reg.FirstName,
+			LastName:    reg.LastName,
+			Role:        "member",
+			Application: reg.Application,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	status, body, err := p.do(ctx, http.MethodPost,
+		"https://"+p.cfg.Domain+"/dbconnections/signup",
+		bytes.NewReader(payload), "")
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		err := fmt.Errorf("auth0 signup failed with status %d: %s", status, body)
+		var failure struct {
+			Code string `json:"code"`
+		}
+		if json.Unmarshal(body, &failure) == nil && (failure.Code == "user_exists" || failure.Code == "invalid_signup") {
+			err = fmt.Errorf("%w: %w", err, domain.ErrConflict)
+		}
+		return nil, err
+	}
+
+	var signup struct {
+		ID string `json:"_id"`
+	}
+	if err := json.Unmarshal(body, &signup); err != nil {
+		return nil, err
+	}
+
+	user, err := domain.NewUser(p.passwords, reg.FirstName, reg.LastName, reg.Email, reg.Password, reg.CreatorID, reg.Application)
+	if err != nil {
+		fmt.Println("Error creating user:", err)
+		return nil, err
+	}
+	if signup.ID != "" {
+		user.Meta[auth0UserIDKey] = "auth0|" + signup.ID
+	}
+
+	if err := p.users.Create(ctx, user); err != nil {
+		fmt.Println("Error creating user in MongoDB:", err)
+		return nil, err
+	}
+	return user, nil
+}
+
+// Disable blocks the Auth0 account and suspends the local user.
+func (p *Auth0Provider) Disable(ctx context.Context, id primitive.ObjectID, actorID string) error {
+	return changeStatus(ctx, p.users, id,
+		func(u *domain.User) error { return u.Suspend(actorID) },
+		func(u *domain.User) error { return p.setBlocked(ctx, u, true) })
+}
+
+// Enable unblocks the Auth0 account and reinstates the local user.
+func (p *Auth0Provider) Enable(ctx context.Context, id primitive.ObjectID, actorID string) error {
+	return changeStatus(ctx, p.users, id,
+		func(u *domain.User) error { return u.Reinstate(actorID) },
+		func(u *domain.User) error { return p.setBlocked(ctx, u, false) })
+}
+
+func (p *Auth0Provider) setBlocked(ctx context.Context, u *domain.User, blocked bool) error {
+	auth0ID, ok := u.Meta[auth0UserIDKey].(string)
+	if !ok {
+		return nil
+	}
+	return p.management(ctx, http.MethodPatch, "/api/v2/users/"+url.PathEscape(auth0ID), map[string]any{"blocked": blocked})
+}
+
+// Delete blocks the Auth0 account and soft-deletes the local user. The
+// account is kept rather than deleted so that Restore can give it back.
+func (p *Auth0Provider) Delete(ctx context.Context, id primitive.ObjectID, actorID string) error {
+	user, err := p.users.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := p.setBlocked(ctx, user, true); err != nil {
+		return err
+	}
+	return p.users.Delete(ctx, id, actorID)
+}
+
+// Restore unblocks the Auth0 account of a restored user, unless the user's
+// status keeps them from logging in anyway, as a suspension does.
+func (p *Auth0Provider) Restore(ctx context.Context, u *domain.User) error {
+	if !u.Status.CanAuthenticate() {
+		return nil
+	}
+	return p.setBlocked(ctx, u, false)
+}
+
+// management calls the Auth0 Management API using a client-credentials
+// token for the configured application.
+func (p *Auth0Provider) management(ctx context.Context, method, path string, body any) error {
+	domainURL := "https://" + p.cfg.Domain
+
+	tokenReq, _ := json.Marshal(map[string]string{
+		"grant_type":    "client_credentials",
+		"client_id":     p.cfg.ClientID,
+		"client_secret": p.cfg.ClientSecret,
+		"audience":      domainURL + "/api/v2/",
+	})
+	status, respBody, err := p.do(ctx, http.MethodPost, domainURL+"/oauth/token", bytes.NewReader(tokenReq), "")
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("auth0 management token failed with status %d: %s", status, respBody)
+	}
+	var token Auth0TokenResponse
+	if err := json.Unmarshal(respBody, &token); err != nil {
+		return err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+	status, respBody, err = p.do(ctx, method, domainURL+path, reader, token.AccessToken)
+	if err != nil {
+		return err
+	}
+	if status >= 300 {
+		return fmt.Errorf("auth0 %s %s failed with status %d: %s", method, path, status, respBody)
+	}
+	return nil
+}
+
+// do sends one request to Auth0 within the caller's deadline, further
+// bounded by the provider's timeout when it is positive, and returns the
+// status and body.
+func (p *Auth0Provider) do(ctx context.Context, method, url string, body io.Reader, bearer string) (int, []byte, error) {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, respBody, err
+}
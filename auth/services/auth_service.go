@@ -2,18 +2,11 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+
 	"github.com/rupesh-sengar/golang-collection/auth/domain"
-	"github.com/rupesh-sengar/golang-collection/auth/infra/mongo_config"
 	"github.com/rupesh-sengar/golang-collection/auth/utils/types"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
-	"io"
-	"net/http"
-	"os"
-	"strings"
-	"time"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Auth0TokenResponse struct {
@@ -24,131 +17,116 @@ type Auth0TokenResponse struct {
 	IDToken      string `json:"id_token,omitempty"`
 }
 
-func Auth0Login(username, password string) (*Auth0TokenResponse, error) {
-	payload := fmt.Sprintf(`{
-		"grant_type": "password",
-		"username": "%s",
-		"password": "%s",
-		"audience": "%s",
-		"client_id": "%s",
-		"client_secret": "%s",
-		"scope": "openid profile email"
-	}`,
-		username,
-		password,
-		os.Getenv("AUTH0_AUDIENCE"),
-		os.Getenv("AUTH0_CLIENT_ID"),
-		os.Getenv("AUTH0_CLIENT_SECRET"),
-	)
-
-	resp, err := http.Post(
-		"https://"+os.Getenv("AUTH0_DOMAIN")+"/oauth/token",
-		"application/json",
-		strings.NewReader(payload),
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+// AuthService covers user lifecycle operations. Changes that an external
+// directory must also see go through the user's identity provider.
+type AuthService struct {
+	users     domain.UserRepository
+	providers *ProviderRegistry
+}
 
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Auth0 error: %s", body)
-	}
+func NewAuthService(users domain.UserRepository, providers *ProviderRegistry) *AuthService {
+	return &AuthService{users: users, providers: providers}
+}
 
-	var token Auth0TokenResponse
-	if err := json.Unmarshal(body, &token); err != nil {
-		return nil, err
+// UserStatus reports the status of the user with email in application.
+func (s *AuthService) UserStatus(ctx context.Context, email, application string) (domain.UserStatus, error) {
+	user, err := s.users.FindByEmail(ctx, domain.Email(email), application)
+	if err != nil {
+		fmt.Println("Error finding user by email:", err)
+		return "", err
 	}
-	return &token, nil
+	return user.Status, nil
 }
 
-func Auth0Signup(req types.SignupRequest, password string) (*http.Response, error) {
-	auth0Payload :=
-		fmt.Sprintf(`{
-	  		"client_id": "%s",
-	 	   	"email": "%s",
-		   	"password": "%s",
-			"connection": "Username-Password-Authentication",
-			"user_metadata":{
-				"first_name": "%s",
-				"last_name": "%s",
-				"role":"member",
-				"application": "%s"
-				}
-	  }`,
-			os.Getenv("AUTH0_CLIENT_ID"),
-			req.Email,
-			password,
-			req.FirstName,
-			req.LastName,
-			req.Application,
-		)
-
-	fmt.Println("Auth0 signup payload: ", auth0Payload)
-	resp, err := http.Post(
-		"https://"+os.Getenv("AUTH0_DOMAIN")+"/dbconnections/signup",
-		"application/json",
-		strings.NewReader(auth0Payload))
-
-	fmt.Println("Auth0 signup response: ", resp)
+// Approve approves a pending user. approverID and application must come
+// from the authenticated caller, never from the request body: an admin may
+// only approve users of their own application. An empty application
+// approves across applications and is for operator tooling only.
+func (s *AuthService) Approve(ctx context.Context, req types.AuthApprovalRequest, approverID, application string) error {
+	err := changeStatus(ctx, s.users, req.Id, func(u *domain.User) error {
+		if application != "" && u.Application != application {
+			return fmt.Errorf("user %s belongs to another application: %w", u.ID.Hex(), domain.ErrForbidden)
+		}
+		return u.Approve(approverID)
+	}, nil)
 	if err != nil {
-		return nil, err
+		fmt.Println("Error approving user:", err)
+		return err
 	}
+	return nil
+}
 
-	defer resp.Body.Close()
+// Reject turns down a pending registration.
+func (s *AuthService) Reject(ctx context.Context, u *domain.User, actorID string) error {
+	return changeStatus(ctx, s.users, u.ID, func(user *domain.User) error { return user.Reject(actorID) }, nil)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("auth0 signup failed with status %d: %s", resp.StatusCode, body)
-	}
+// Users lists the users matching filter, oldest first.
+func (s *AuthService) Users(ctx context.Context, filter domain.UserFilter) ([]*domain.User, error) {
+	return s.users.List(ctx, filter)
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	clientOpts := options.Client().ApplyURI(os.Getenv("MONGODB_URI"))
-	client, err := mongo.Connect(ctx, clientOpts)
-	if err != nil {
-		fmt.Println("mongo connect error: %v", err)
+// User finds a live user by ObjectID hex or by email. Emails are only unique
+// within an application, so an email that more than one application uses
+// needs application to pick one; an ID is checked against application when
+// it is given.
+func (s *AuthService) User(ctx context.Context, ref, application string) (*domain.User, error) {
+	if id, err := primitive.ObjectIDFromHex(ref); err == nil {
+		u, err := s.users.FindByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if application != "" && u.Application != application {
+			return nil, fmt.Errorf("user %s in application %s: %w", ref, application, domain.ErrNotFound)
+		}
+		return u, nil
 	}
-	defer client.Disconnect(ctx)
-	userCollection := client.Database("User-Management").Collection("Users")
 
-	userRepo := mongo_config.NewUserRepository(userCollection)
-	mongo_config.EnsureUserIndexes(ctx, userCollection)
-	domainRes, err := domain.NewUser(req.FirstName, req.LastName, req.Email, req.Password, req.CreatorID, req.Application)
+	users, err := s.users.List(ctx, domain.UserFilter{Application: application, Email: domain.Email(ref)})
 	if err != nil {
-		fmt.Println("Error creating user:", err)
 		return nil, err
 	}
-
-	createErr := userRepo.Create(ctx, domainRes)
-
-	if createErr != nil {
-		fmt.Println("Error creating user in MongoDB:", createErr)
-		return nil, createErr
+	switch len(users) {
+	case 0:
+		if application != "" {
+			return nil, fmt.Errorf("user %s in application %s: %w", ref, application, domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("user %s: %w", ref, domain.ErrNotFound)
+	case 1:
+		return users[0], nil
+	default:
+		return nil, fmt.Errorf("%d users in different applications have email %s; choose one by application or ID", len(users), ref)
 	}
+}
 
-	return nil, nil
+// Suspend blocks u from logging in, in its identity provider as well.
+func (s *AuthService) Suspend(ctx context.Context, u *domain.User, actorID string) error {
+	return s.providers.For(u.Application).Disable(ctx, u.ID, actorID)
 }
 
-func AuthApprovalService(req types.AuthApprovalRequest) (*http.Response, error){
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	clientOpts := options.Client().ApplyURI(os.Getenv("MONGODB_URI"))
-	client, err := mongo.Connect(ctx, clientOpts)
-	if err != nil {
-		fmt.Println("mongo connect error: %v", err)
-	}
-	defer client.Disconnect(ctx)
-	userCollection := client.Database("User-Management").Collection("Users")
+// Reactivate lifts a suspension.
+func (s *AuthService) Reactivate(ctx context.Context, u *domain.User, actorID string) error {
+	return s.providers.For(u.Application).Enable(ctx, u.ID, actorID)
+}
 
-	userRepo := mongo_config.NewUserRepository(userCollection)
-	mongo_config.EnsureUserIndexes(ctx, userCollection)
+// Delete soft-deletes u and closes its account with its identity provider.
+func (s *AuthService) Delete(ctx context.Context, u *domain.User, actorID string) error {
+	return s.providers.For(u.Application).Delete(ctx, u.ID, actorID)
+}
 
-	err = userRepo.Approve(ctx, req.Id, req.ApproverID)
+// Restore undoes a soft delete, and reopens the user's account with their
+// identity provider.
+func (s *AuthService) Restore(ctx context.Context, id primitive.ObjectID, actorID string) error {
+	if err := s.users.Restore(ctx, id, actorID); err != nil {
+		return err
+	}
+	u, err := s.users.FindByID(ctx, id)
 	if err != nil {
-		fmt.Println("Error approving user:", err)
-		return nil, err
+		return err
+	}
+	provider := s.providers.For(u.Application)
+	if err := provider.Restore(ctx, u); err != nil {
+		return fmt.Errorf("user %s was restored, but its %s account is still blocked: %w", id.Hex(), provider.Name(), err)
 	}
-	return nil, nil
+	return nil
 }
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rupesh-sengar/golang-collection/auth/config"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"github.com/rupesh-sengar/golang-collection/auth/utils/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newAuthService(t *testing.T, users ...*domain.User) (*AuthService, *memUsers) {
+	t.Helper()
+	repo := newMemUsers(users...)
+	cfg := config.Default()
+	cfg.Identity.Provider = ProviderLocal
+	providers, err := NewProviderRegistry(repo, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewAuthService(repo, providers), repo
+}
+
+func user(email, application string, status domain.UserStatus) *domain.User {
+	return &domain.User{
+		ID:          primitive.NewObjectID(),
+		Email:       domain.Email(email),
+		Application: application,
+		Status:      status,
+	}
+}
+
+func TestAuthServiceUser(t *testing.T) {
+	ctx := context.Background()
+	shop := user("a@example.com", "shop", domain.StatusActive)
+	blog := user("a@example.com", "blog", domain.StatusActive)
+	solo := user("b@example.com", "shop", domain.StatusActive)
+	auth, _ := newAuthService(t, shop, blog, solo)
+
+	tests := []struct {
+		name        string
+		ref, app    string
+		want        *domain.User
+		wantErr     error
+		wantMessage string
+	}{
+		{name: "by ID", ref: shop.ID.Hex(), want: shop},
+		{name: "by ID in its application", ref: shop.ID.Hex(), app: "shop", want: shop},
+		{name: "by ID in another application", ref: shop.ID.Hex(), app: "blog", wantErr: domain.ErrNotFound},
+		{name: "unique email", ref: "b@example.com", want: solo},
+		{name: "shared email with application", ref: "a@example.com", app: "blog", want: blog},
+		{name: "shared email without application", ref: "a@example.com", wantMessage: "choose one by application"},
+		{name: "unknown email", ref: "c@example.com", wantErr: domain.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := auth.User(ctx, tt.ref, tt.app)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantMessage != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantMessage) {
+					t.Errorf("err = %v, want one mentioning %q", err, tt.wantMessage)
+				}
+			case err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case got.ID != tt.want.ID:
+				t.Errorf("found %s, want %s", got.ID.Hex(), tt.want.ID.Hex())
+			}
+		})
+	}
+}
+
+func TestAuthServiceUserStatus(t *testing.T) {
+	ctx := context.Background()
+	auth, _ := newAuthService(t,
+		user("a@example.com", "shop", domain.StatusActive),
+		user("a@example.com", "blog", domain.StatusSuspended))
+
+	tests := []struct {
+		app     string
+		want    domain.UserStatus
+		wantErr error
+	}{
+		{app: "shop", want: domain.StatusActive},
+		{app: "blog", want: domain.StatusSuspended},
+		{app: "wiki", wantErr: domain.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.app, func(t *testing.T) {
+			got, err := auth.UserStatus(ctx, "a@example.com", tt.app)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("status = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthServiceDeleteRestore(t *testing.T) {
+	ctx := context.Background()
+	u := user("a@example.com", "shop", domain.StatusActive)
+	auth, repo := newAuthService(t, u)
+
+	if err := auth.Delete(ctx, u, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.User(ctx, u.ID.Hex(), ""); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("deleted user still found: %v", err)
+	}
+	if err := auth.Restore(ctx, u.ID, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if restored, err := repo.FindByID(ctx, u.ID); err != nil || restored.Status != domain.StatusActive {
+		t.Errorf("restored user = %+v, %v", restored, err)
+	}
+	if err := auth.Restore(ctx, u.ID, "admin"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("restoring a live user err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestAuthServiceApproveIsScopedToApplication(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name        string
+		application string
+		wantErr     error
+	}{
+		{"admin of the user's application", "shop", nil},
+		{"admin of another application", "blog", domain.ErrForbidden},
+		{"operator tooling", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := user("a@example.com", "shop", domain.StatusPending)
+			auth, repo := newAuthService(t, u)
+			err := auth.Approve(ctx, types.AuthApprovalRequest{Id: u.ID}, "admin-1", tt.application)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			stored, _ := repo.FindByID(ctx, u.ID)
+			want := domain.StatusApproved
+			if tt.wantErr != nil {
+				want = domain.StatusPending
+			}
+			if stored.Status != want {
+				t.Errorf("status = %s, want %s", stored.Status, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rupesh-sengar/golang-collection/auth/config"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"github.com/rupesh-sengar/golang-collection/auth/keyring"
+	"github.com/rupesh-sengar/golang-collection/auth/token"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memUsers is an in-memory domain.UserRepository.
+type memUsers struct {
+	mu    sync.Mutex
+	users map[primitive.ObjectID]*domain.User
+}
+
+func newMemUsers(users ...*domain.User) *memUsers {
+	r := &memUsers{users: map[primitive.ObjectID]*domain.User{}}
+	for _, u := range users {
+		r.users[u.ID] = u
+	}
+	return r
+}
+
+func (r *memUsers) Create(ctx context.Context, u *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, other := range r.users {
+		if other.Email == u.Email && other.Application == u.Application {
+			return fmt.Errorf("user %s: %w", u.Email, domain.ErrConflict)
+		}
+	}
+	copied := *u
+	r.users[u.ID] = &copied
+	return nil
+}
+
+func (r *memUsers) FindByID(ctx context.Context, id primitive.ObjectID) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || u.Audit.Deleted {
+		return nil, fmt.Errorf("user %s: %w", id.Hex(), domain.ErrNotFound)
+	}
+	copied := *u
+	return &copied, nil
+}
+
+func (r *memUsers) FindByEmail(ctx context.Context, email domain.Email, application string) (*domain.User, error) {
+	users, _ := r.List(ctx, domain.UserFilter{Email: email, Application: application})
+	if application == "" || len(users) == 0 {
+		return nil, fmt.Errorf("user %s: %w", email, domain.ErrNotFound)
+	}
+	return users[0], nil
+}
+
+func (r *memUsers) FindByApplication(ctx context.Context, applicationID string) ([]*domain.User, error) {
+	return r.List(ctx, domain.UserFilter{Application: applicationID})
+}
+
+func (r *memUsers) List(ctx context.Context, f domain.UserFilter) ([]*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var users []*domain.User
+	for _, u := range r.users {
+		if u.Audit.Deleted != f.Deleted ||
+			(f.Application != "" && u.Application != f.Application) ||
+			(f.Email != "" && u.Email != f.Email) ||
+			(f.Status != "" && u.Status != f.Status) {
+			continue
+		}
+		copied := *u
+		users = append(users, &copied)
+	}
+	return users, nil
+}
+
+func (r *memUsers) UpdateStatus(ctx context.Context, u *domain.User, from domain.UserStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.users[u.ID]
+	if !ok || stored.Audit.Deleted {
+		return fmt.Errorf("user %s: %w", u.ID.Hex(), domain.ErrNotFound)
+	}
+	if stored.Status != from {
+		return fmt.Errorf("user %s is %s, not %s: %w", u.ID.Hex(), stored.Status, from, domain.ErrInvalidTransition)
+	}
+	stored.Status = u.Status
+	stored.ApprovedBy = u.ApprovedBy
+	stored.Audit.UpdatedBy = u.Audit.UpdatedBy
+	return nil
+}
+
+func (r *memUsers) setDeleted(id primitive.ObjectID, deleted bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || u.Audit.Deleted == deleted {
+		return fmt.Errorf("user %s: %w", id.Hex(), domain.ErrNotFound)
+	}
+	u.Audit.Deleted = deleted
+	return nil
+}
+
+func (r *memUsers) Delete(ctx context.Context, id primitive.ObjectID, actorID string) error {
+	return r.setDeleted(id, true)
+}
+
+func (r *memUsers) Restore(ctx context.Context, id primitive.ObjectID, actorID string) error {
+	return r.setDeleted(id, false)
+}
+
+func (r *memUsers) Update(ctx context.Context, u *domain.User, actorID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *u
+	r.users[u.ID] = &copied
+	return nil
+}
+
+// memRefreshTokens is an in-memory domain.RefreshTokenRepository.
+type memRefreshTokens struct {
+	mu     sync.Mutex
+	tokens []*domain.RefreshToken
+}
+
+func (r *memRefreshTokens) Create(ctx context.Context, t *domain.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *t
+	copied.ID = primitive.NewObjectID()
+	r.tokens = append(r.tokens, &copied)
+	return nil
+}
+
+func (r *memRefreshTokens) FindByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		if t.Hash == hash {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("refresh token: %w", domain.ErrNotFound)
+}
+
+func (r *memRefreshTokens) MarkUsed(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		if t.ID == id {
+			if t.UsedAt != nil || t.Revoked {
+				return false, nil
+			}
+			t.UsedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memRefreshTokens) RevokeFamily(ctx context.Context, familyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		if t.FamilyID == familyID {
+			t.Revoked = true
+		}
+	}
+	return nil
+}
+
+// family returns the tokens of familyID, oldest first.
+func (r *memRefreshTokens) family(familyID string) []domain.RefreshToken {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var tokens []domain.RefreshToken
+	for _, t := range r.tokens {
+		if t.FamilyID == familyID {
+			tokens = append(tokens, *t)
+		}
+	}
+	return tokens
+}
+
+// memRevocations is an in-memory domain.RevocationRepository.
+type memRevocations struct {
+	mu      sync.Mutex
+	revoked map[string]*domain.RevokedToken
+}
+
+func (r *memRevocations) Revoke(ctx context.Context, t *domain.RevokedToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.revoked == nil {
+		r.revoked = map[string]*domain.RevokedToken{}
+	}
+	r.revoked[t.ID] = t
+	return nil
+}
+
+func (r *memRevocations) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.revoked[jti]
+	return ok, nil
+}
+
+var (
+	testKeyOnce sync.Once
+	testKey     *rsa.PrivateKey
+)
+
+var testTokenSettings = config.TokenSettings{
+	Issuer:          "https://auth.example.com",
+	Audience:        "api",
+	AccessTokenTTL:  time.Minute,
+	IDTokenTTL:      time.Minute,
+	RefreshTokenTTL: time.Hour,
+}
+
+// tokenFixture is a TokenService over in-memory repositories holding one
+// active user.
+type tokenFixture struct {
+	svc           *TokenService
+	user          *domain.User
+	users         *memUsers
+	refreshTokens *memRefreshTokens
+	revocations   *memRevocations
+}
+
+func newTokenFixture(t *testing.T) *tokenFixture {
+	t.Helper()
+	testKeyOnce.Do(func() {
+		k, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			panic(err)
+		}
+		testKey = k
+	})
+	user := &domain.User{
+		ID:          primitive.NewObjectID(),
+		Email:       "a@example.com",
+		Roles:       []domain.UserRole{domain.RoleMember},
+		Application: "shop",
+		Status:      domain.StatusActive,
+	}
+	f := &tokenFixture{
+		user:          user,
+		users:         newMemUsers(user),
+		refreshTokens: &memRefreshTokens{},
+		revocations:   &memRevocations{},
+	}
+	signer := token.NewSigner(keyring.Single(testKey), testTokenSettings)
+	f.svc = NewTokenService(f.users, f.refreshTokens, f.revocations, nil, signer, config.IntrospectionConfig{})
+	return f
+}
+
+// login issues a token set for the fixture's user as a fresh login would.
+func (f *tokenFixture) login(t *testing.T) *Auth0TokenResponse {
+	t.Helper()
+	family, err := randomToken(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := f.svc.issueTokens(context.Background(), f.user, family)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokens
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/rupesh-sengar/golang-collection/auth/config"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidCredentials = fmt.Errorf("invalid credentials: %w", domain.ErrUnauthorized)
+
+type Credentials struct {
+	Username    string
+	Password    string
+	Application string
+}
+
+type Registration struct {
+	FirstName   string
+	LastName    string
+	Email       string
+	Password    string
+	Application string
+	// CreatorID is the authenticated actor, or empty for self-registration.
+	CreatorID string
+}
+
+// Identity is the result of a successful Authenticate. Token is only set by
+// providers that issue their own tokens.
+type Identity struct {
+	User  *domain.User
+	Token *Auth0TokenResponse
+}
+
+// IdentityProvider checks and manages user credentials. Every provider keeps
+// the user record in domain.UserRepository; providers backed by an external
+// directory mirror their changes there.
+type IdentityProvider interface {
+	Name() string
+
+	Authenticate(ctx context.Context, creds Credentials) (*Identity, error)
+
+	Register(ctx context.Context, reg Registration) (*domain.User, error)
+
+	Disable(ctx context.Context, id primitive.ObjectID, actorID string) error
+
+	Enable(ctx context.Context, id primitive.ObjectID, actorID string) error
+
+	Delete(ctx context.Context, id primitive.ObjectID, actorID string) error
+
+	// Restore reopens the provider's account for u once
+	// UserRepository.Restore has brought the local record back.
+	Restore(ctx context.Context, u *domain.User) error
+}
+
+const (
+	ProviderAuth0 = "auth0"
+	ProviderLocal = "local"
+)
+
+// ProviderRegistry picks the identity provider for each application.
+type ProviderRegistry struct {
+	defaultProvider IdentityProvider
+	overrides       map[string]IdentityProvider
+}
+
+// NewProviderRegistry builds the default provider and the per-application
+// overrides named in cfg.Identity.
+func NewProviderRegistry(users domain.UserRepository, cfg *config.Config) (*ProviderRegistry, error) {
+	p, err := newIdentityProvider(cfg.Identity.Provider, users, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := map[string]IdentityProvider{}
+	for app, providerName := range cfg.Identity.Overrides {
+		op, err := newIdentityProvider(providerName, users, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("application %s: %w", app, err)
+		}
+		overrides[app] = op
+	}
+
+	return &ProviderRegistry{defaultProvider: p, overrides: overrides}, nil
+}
+
+// For returns the identity provider configured for application.
+func (r *ProviderRegistry) For(application string) IdentityProvider {
+	if p, ok := r.overrides[application]; ok {
+		return p
+	}
+	return r.defaultProvider
+}
+
+func newIdentityProvider(name string, users domain.UserRepository, cfg *config.Config) (IdentityProvider, error) {
+	switch name {
+	case ProviderAuth0:
+		if cfg.Auth0.Domain == "" {
+			return nil, errors.New("auth0 identity provider requires AUTH0_DOMAIN")
+		}
+		return NewAuth0Provider(users, &cfg.Password, cfg.Auth0, cfg.Timeouts.Auth0), nil
+	case ProviderLocal:
+		return NewLocalProvider(users, &cfg.Password), nil
+	default:
+		return nil, fmt.Errorf("unknown identity provider %q", name)
+	}
+}
+
+// changeStatus applies a status transition to the user with id and persists
+// it against the status the user had when loaded. sync, when set, mirrors the
+// change to an external directory; it runs only once the transition is known
+// to be legal.
+func changeStatus(ctx context.Context, users domain.UserRepository, id primitive.ObjectID, change func(*domain.User) error, sync func(*domain.User) error) error {
+	user, err := users.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	from := user.Status
+	if err := change(user); err != nil {
+		return err
+	}
+	if sync != nil {
+		if err := sync(user); err != nil {
+			return err
+		}
+	}
+	return users.UpdateStatus(ctx, user, from)
+}
+
+// upgradePasswordHash re-hashes u's stored password when it was written with
+// an outdated algorithm or parameters, or was never hashed at all. It must
+// only be called after password has been verified.
+func upgradePasswordHash(ctx context.Context, repo domain.UserRepository, passwords *domain.PasswordHasher, u *domain.User, password string) {
+	if !u.PasswordNeedsRehash(passwords) {
+		return
+	}
+	if err := u.SetPassword(passwords, password); err != nil {
+		log.Printf("Password rehash failed for user %s: %v", u.ID.Hex(), err)
+		return
+	}
+	if err := repo.Update(ctx, u, u.ID.Hex()); err != nil {
+		log.Printf("Password rehash failed for user %s: %v", u.ID.Hex(), err)
+	}
+}
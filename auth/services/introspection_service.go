@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"github.com/rupesh-sengar/golang-collection/auth/token"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Introspection is an RFC 7662 response. Inactive tokens carry nothing but
+// Active=false.
+type Introspection struct {
+	Active      bool              `json:"active"`
+	Scope       string            `json:"scope,omitempty"`
+	ClientID    string            `json:"client_id,omitempty"`
+	TokenType   string            `json:"token_type,omitempty"`
+	Exp         int64             `json:"exp,omitempty"`
+	Iat         int64             `json:"iat,omitempty"`
+	Sub         string            `json:"sub,omitempty"`
+	Aud         []string          `json:"aud,omitempty"`
+	Iss         string            `json:"iss,omitempty"`
+	Jti         string            `json:"jti,omitempty"`
+	Email       domain.Email      `json:"email,omitempty"`
+	Status      domain.UserStatus `json:"status,omitempty"`
+	Roles       []domain.UserRole `json:"roles,omitempty"`
+	Application string            `json:"application,omitempty"`
+}
+
+// AuthenticateClient checks an introspection client's credentials.
+func (s *TokenService) AuthenticateClient(id, secret string) bool {
+	want, ok := s.clients[id]
+	match := subtle.ConstantTimeCompare([]byte(want), []byte(secret)) == 1
+	return ok && match
+}
+
+// Introspect reports whether raw is currently usable. The owning user is
+// looked up on every call, so suspending or deleting a user deactivates
+// their outstanding tokens immediately. hint only sets which token type is
+// tried first; ID tokens are never reported as active.
+func (s *TokenService) Introspect(ctx context.Context, raw, hint string) (*Introspection, error) {
+	for _, typ := range tokenTypes(raw, hint) {
+		introspect := s.introspectAccessToken
+		if typ == hintRefreshToken {
+			introspect = s.introspectRefreshToken
+		}
+		resp, err := introspect(ctx, raw)
+		if err != nil || resp != nil {
+			return resp, err
+		}
+	}
+	return &Introspection{Active: false}, nil
+}
+
+// introspectAccessToken returns nil when raw is not one of this service's
+// access tokens, so the caller can try it as a refresh token.
+func (s *TokenService) introspectAccessToken(ctx context.Context, raw string) (*Introspection, error) {
+	inactive := &Introspection{Active: false}
+	claims, err := s.verifyAccessToken(ctx, raw)
+	if errors.Is(err, ErrTokenRevoked) {
+		return inactive, nil
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	userID, err := primitive.ObjectIDFromHex(claims.Subject)
+	if err != nil {
+		return inactive, nil
+	}
+	user, active, err := s.liveUser(ctx, userID)
+	if err != nil || !active {
+		return inactive, err
+	}
+
+	resp := &Introspection{
+		Active:      true,
+		Scope:       claims.Scope,
+		ClientID:    claims.Application,
+		TokenType:   "Bearer",
+		Sub:         claims.Subject,
+		Aud:         claims.Audience,
+		Iss:         claims.Issuer,
+		Jti:         claims.ID,
+		Email:       user.Email,
+		Status:      user.Status,
+		Roles:       user.Roles,
+		Application: user.Application,
+	}
+	if claims.ExpiresAt != nil {
+		resp.Exp = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		resp.Iat = claims.IssuedAt.Unix()
+	}
+	return resp, nil
+}
+
+// introspectRefreshToken returns nil when raw is not a refresh token this
+// service issued.
+func (s *TokenService) introspectRefreshToken(ctx context.Context, raw string) (*Introspection, error) {
+	inactive := &Introspection{Active: false}
+	stored, err := s.findRefreshToken(ctx, raw)
+	if err != nil || stored == nil {
+		return nil, err
+	}
+	if stored.Revoked || stored.UsedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return inactive, nil
+	}
+
+	user, active, err := s.liveUser(ctx, stored.UserID)
+	if err != nil || !active {
+		return inactive, err
+	}
+	return &Introspection{
+		Active:      true,
+		Scope:       token.DefaultScope,
+		ClientID:    stored.Application,
+		TokenType:   "refresh_token",
+		Exp:         stored.ExpiresAt.Unix(),
+		Iat:         stored.IssuedAt.Unix(),
+		Sub:         stored.UserID.Hex(),
+		Iss:         s.settings.Issuer,
+		Email:       user.Email,
+		Status:      user.Status,
+		Roles:       user.Roles,
+		Application: user.Application,
+	}, nil
+}
+
+// liveUser loads the user behind a token and reports whether they may still
+// use it.
+func (s *TokenService) liveUser(ctx context.Context, id primitive.ObjectID) (*domain.User, bool, error) {
+	user, err := s.users.FindByID(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return user, user.Status.CanAuthenticate(), nil
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+)
+
+func TestIntrospect(t *testing.T) {
+	ctx := context.Background()
+	for _, hint := range []string{"", hintAccessToken, hintRefreshToken} {
+		t.Run("hint "+hint, func(t *testing.T) {
+			f := newTokenFixture(t)
+			tokens := f.login(t)
+
+			access, err := f.svc.Introspect(ctx, tokens.AccessToken, hint)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !access.Active || access.TokenType != "Bearer" || access.Sub != f.user.ID.Hex() || access.Jti == "" {
+				t.Errorf("access token introspection = %+v", access)
+			}
+
+			refresh, err := f.svc.Introspect(ctx, tokens.RefreshToken, hint)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !refresh.Active || refresh.TokenType != "refresh_token" || refresh.Sub != f.user.ID.Hex() {
+				t.Errorf("refresh token introspection = %+v", refresh)
+			}
+		})
+	}
+}
+
+func TestIntrospectInactive(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		token func(t *testing.T, f *tokenFixture) string
+	}{
+		{"ID token", func(t *testing.T, f *tokenFixture) string { return f.login(t).IDToken }},
+		{"garbage", func(t *testing.T, f *tokenFixture) string { return "not-a-token" }},
+		{"revoked access token", func(t *testing.T, f *tokenFixture) string {
+			raw := f.login(t).AccessToken
+			if err := f.svc.RevokeToken(ctx, raw, hintAccessToken); err != nil {
+				t.Fatal(err)
+			}
+			return raw
+		}},
+		{"used refresh token", func(t *testing.T, f *tokenFixture) string {
+			raw := f.login(t).RefreshToken
+			if _, err := f.svc.Refresh(ctx, raw); err != nil {
+				t.Fatal(err)
+			}
+			return raw
+		}},
+		{"suspended user's access token", func(t *testing.T, f *tokenFixture) string {
+			raw := f.login(t).AccessToken
+			f.users.users[f.user.ID].Status = domain.StatusSuspended
+			return raw
+		}},
+		{"deleted user's refresh token", func(t *testing.T, f *tokenFixture) string {
+			raw := f.login(t).RefreshToken
+			f.users.users[f.user.ID].Audit.Deleted = true
+			return raw
+		}},
+	}
+	for _, tt := range tests {
+		for _, hint := range []string{"", hintAccessToken, hintRefreshToken} {
+			t.Run(tt.name+", hint "+hint, func(t *testing.T) {
+				f := newTokenFixture(t)
+				resp, err := f.svc.Introspect(ctx, tt.token(t, f), hint)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.Active {
+					t.Errorf("introspection = %+v, want inactive", resp)
+				}
+			})
+		}
+	}
+}
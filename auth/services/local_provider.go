@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LocalProvider authenticates against the password hashes stored in the
+// user repository, so no external directory is needed.
+type LocalProvider struct {
+	users     domain.UserRepository
+	passwords *domain.PasswordHasher
+}
+
+// NewLocalProvider builds a provider that hashes and checks passwords with
+// passwords.
+func NewLocalProvider(users domain.UserRepository, passwords *domain.PasswordHasher) *LocalProvider {
+	return &LocalProvider{users: users, passwords: passwords}
+}
+
+func (p *LocalProvider) Name() string { return ProviderLocal }
+
+func (p *LocalProvider) Authenticate(ctx context.Context, creds Credentials) (*Identity, error) {
+	user, err := p.users.FindByEmail(ctx, domain.Email(creds.Username), creds.Application)
+	if errors.Is(err, domain.ErrNotFound) {
+		// Burn the same time as a real check so unknown emails are not
+		// distinguishable by latency.
+		p.passwords.Hash(creds.Password)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := user.CheckPassword(p.passwords, creds.Password)
+	if err != nil && !errors.Is(err, domain.ErrUnknownHashFormat) && !errors.Is(err, domain.ErrInvalidHash) {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	upgradePasswordHash(ctx, p.users, p.passwords, user, creds.Password)
+
+	return &Identity{User: user}, nil
+}
+
+func (p *LocalProvider) Register(ctx context.Context, reg Registration) (*domain.User, error) {
+	user, err := domain.NewUser(p.passwords, reg.FirstName, reg.LastName, reg.Email, reg.Password, reg.CreatorID, reg.Application)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.users.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (p *LocalProvider) Disable(ctx context.Context, id primitive.ObjectID, actorID string) error {
+	return changeStatus(ctx, p.users, id, func(u *domain.User) error { return u.Suspend(actorID) }, nil)
+}
+
+func (p *LocalProvider) Enable(ctx context.Context, id primitive.ObjectID, actorID string) error {
+	return changeStatus(ctx, p.users, id, func(u *domain.User) error { return u.Reinstate(actorID) }, nil)
+}
+
+func (p *LocalProvider) Delete(ctx context.Context, id primitive.ObjectID, actorID string) error {
+	return p.users.Delete(ctx, id, actorID)
+}
+
+// Restore has nothing to do: the local record is the whole account.
+func (p *LocalProvider) Restore(ctx context.Context, u *domain.User) error {
+	return nil
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+)
+
+func TestLocalProviderAuthenticateScopesByApplication(t *testing.T) {
+	ctx := context.Background()
+	passwords := domain.DefaultPasswordHasher()
+	shop, err := domain.NewUser(&passwords, "Ann", "Shop", "a@example.com", "shop-password", "", "shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	blog, err := domain.NewUser(&passwords, "Ann", "Blog", "a@example.com", "blog-password", "", "blog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := NewLocalProvider(newMemUsers(shop, blog), &passwords)
+
+	tests := []struct {
+		name    string
+		creds   Credentials
+		want    *domain.User
+		wantErr error
+	}{
+		{name: "shop", creds: Credentials{Username: "a@example.com", Password: "shop-password", Application: "shop"}, want: shop},
+		{name: "blog", creds: Credentials{Username: "a@example.com", Password: "blog-password", Application: "blog"}, want: blog},
+		{name: "password of the other application", creds: Credentials{Username: "a@example.com", Password: "blog-password", Application: "shop"}, wantErr: ErrInvalidCredentials},
+		{name: "unknown application", creds: Credentials{Username: "a@example.com", Password: "shop-password", Application: "wiki"}, wantErr: ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := provider.Authenticate(ctx, tt.creds)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case identity.User.ID != tt.want.ID:
+				t.Errorf("authenticated %s, want %s", identity.User.ID.Hex(), tt.want.ID.Hex())
+			}
+		})
+	}
+}
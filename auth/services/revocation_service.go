@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"github.com/rupesh-sengar/golang-collection/auth/middleware"
+)
+
+var ErrTokenRevoked = fmt.Errorf("token has been revoked: %w", domain.ErrUnauthorized)
+
+// IsTokenRevoked reports whether jti is on the denylist. Every path that
+// accepts a JWT from a caller must check it, either directly or through
+// middleware.Config.IsRevoked.
+func (s *TokenService) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return s.revocations.IsRevoked(ctx, jti)
+}
+
+// verifyAccessToken parses raw as one of this service's access tokens and
+// checks it has not been revoked.
+func (s *TokenService) verifyAccessToken(ctx context.Context, raw string) (*middleware.Claims, error) {
+	claims, err := s.signer.ParseAccessToken(raw)
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := s.revocations.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// Logout revokes the caller's access token and, when given, the refresh
+// token family it was issued with.
+func (s *TokenService) Logout(ctx context.Context, claims *middleware.Claims, refreshToken string) error {
+	if err := s.denyClaims(ctx, claims); err != nil {
+		return err
+	}
+	if refreshToken == "" {
+		return nil
+	}
+	return s.revokeRefreshFamily(ctx, refreshToken, claims.Subject)
+}
+
+// Token type hints from RFC 7009 and RFC 7662.
+const (
+	hintAccessToken  = "access_token"
+	hintRefreshToken = "refresh_token"
+)
+
+// tokenTypes returns the token types to try for raw, most likely first. The
+// hint only sets the order: RFC 7009 section 2.1 requires trying the other
+// type when the token is not found as the hinted one.
+func tokenTypes(raw, hint string) []string {
+	switch {
+	case hint == hintRefreshToken:
+		return []string{hintRefreshToken, hintAccessToken}
+	case hint == hintAccessToken || strings.Count(raw, ".") == 2:
+		return []string{hintAccessToken, hintRefreshToken}
+	default:
+		return []string{hintRefreshToken, hintAccessToken}
+	}
+}
+
+// RevokeToken implements RFC 7009: it revokes raw if it is an access or
+// refresh token this service issued and silently ignores anything else,
+// including ID tokens.
+func (s *TokenService) RevokeToken(ctx context.Context, raw, hint string) error {
+	for _, typ := range tokenTypes(raw, hint) {
+		if typ == hintRefreshToken {
+			stored, err := s.findRefreshToken(ctx, raw)
+			if err != nil {
+				return err
+			}
+			if stored != nil {
+				return s.refreshTokens.RevokeFamily(ctx, stored.FamilyID)
+			}
+			continue
+		}
+		// Expired, foreign, malformed or not an access token: try the
+		// other type.
+		if claims, err := s.signer.ParseAccessToken(raw); err == nil {
+			return s.denyClaims(ctx, claims)
+		}
+	}
+	return nil
+}
+
+func (s *TokenService) denyClaims(ctx context.Context, claims *middleware.Claims) error {
+	expiresAt := time.Now().UTC()
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	return s.revocations.Revoke(ctx, &domain.RevokedToken{
+		ID:        claims.ID,
+		Subject:   claims.Subject,
+		RevokedAt: time.Now().UTC(),
+		ExpiresAt: expiresAt,
+	})
+}
+
+// revokeRefreshFamily revokes the family raw belongs to. When subject is set
+// the token must have been issued to that user.
+func (s *TokenService) revokeRefreshFamily(ctx context.Context, raw, subject string) error {
+	stored, err := s.findRefreshToken(ctx, raw)
+	if err != nil || stored == nil {
+		return err
+	}
+	if subject != "" && stored.UserID.Hex() != subject {
+		return nil
+	}
+	return s.refreshTokens.RevokeFamily(ctx, stored.FamilyID)
+}
+
+// findRefreshToken looks raw up as a refresh token, returning nil when it
+// is not one.
+func (s *TokenService) findRefreshToken(ctx context.Context, raw string) (*domain.RefreshToken, error) {
+	stored, err := s.refreshTokens.FindByHash(ctx, hashToken(raw))
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	return stored, err
+}
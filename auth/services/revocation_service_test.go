@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRevokeToken(t *testing.T) {
+	ctx := context.Background()
+	for _, hint := range []string{"", hintAccessToken, hintRefreshToken, "unknown_type"} {
+		t.Run("refresh token, hint "+hint, func(t *testing.T) {
+			f := newTokenFixture(t)
+			tokens := f.login(t)
+			if err := f.svc.RevokeToken(ctx, tokens.RefreshToken, hint); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.svc.Refresh(ctx, tokens.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+				t.Errorf("refresh after revocation err = %v", err)
+			}
+		})
+
+		t.Run("access token, hint "+hint, func(t *testing.T) {
+			f := newTokenFixture(t)
+			tokens := f.login(t)
+			if err := f.svc.RevokeToken(ctx, tokens.AccessToken, hint); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.svc.verifyAccessToken(ctx, tokens.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+				t.Errorf("verify after revocation err = %v, want ErrTokenRevoked", err)
+			}
+			// Revoking an access token leaves the refresh token alone.
+			if _, err := f.svc.Refresh(ctx, tokens.RefreshToken); err != nil {
+				t.Errorf("refresh: %v", err)
+			}
+		})
+	}
+}
+
+// RFC 7009 section 2.5: invalid tokens, including ID tokens, are not an
+// error, and nothing is revoked.
+func TestRevokeTokenIgnoresOtherTokens(t *testing.T) {
+	ctx := context.Background()
+	f := newTokenFixture(t)
+	tokens := f.login(t)
+
+	for _, raw := range []string{tokens.IDToken, "not-a-token", "a.b.c"} {
+		for _, hint := range []string{"", hintAccessToken, hintRefreshToken} {
+			if err := f.svc.RevokeToken(ctx, raw, hint); err != nil {
+				t.Errorf("RevokeToken(%.10s..., %q) = %v", raw, hint, err)
+			}
+		}
+	}
+	if len(f.revocations.revoked) != 0 {
+		t.Errorf("revoked %d tokens, want none", len(f.revocations.revoked))
+	}
+	if _, err := f.svc.Refresh(ctx, tokens.RefreshToken); err != nil {
+		t.Errorf("refresh: %v", err)
+	}
+}
+
+func TestTokenTypes(t *testing.T) {
+	tests := []struct {
+		raw, hint string
+		first     string
+	}{
+		{"a.b.c", "", hintAccessToken},
+		{"opaque", "", hintRefreshToken},
+		{"a.b.c", hintRefreshToken, hintRefreshToken},
+		{"opaque", hintAccessToken, hintAccessToken},
+		{"opaque", "bogus", hintRefreshToken},
+	}
+	for _, tt := range tests {
+		got := tokenTypes(tt.raw, tt.hint)
+		if len(got) != 2 || got[0] != tt.first || got[0] == got[1] {
+			t.Errorf("tokenTypes(%q, %q) = %v, want %s first and both types", tt.raw, tt.hint, got, tt.first)
+		}
+	}
+}
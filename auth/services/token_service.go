@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rupesh-sengar/golang-collection/auth/config"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"github.com/rupesh-sengar/golang-collection/auth/token"
+)
+
+var (
+	ErrInvalidRefreshToken = fmt.Errorf("invalid refresh token: %w", domain.ErrUnauthorized)
+	ErrRefreshTokenReused  = fmt.Errorf("refresh token reuse detected: %w", domain.ErrUnauthorized)
+)
+
+// TokenService issues, refreshes, revokes and introspects tokens.
+type TokenService struct {
+	users         domain.UserRepository
+	refreshTokens domain.RefreshTokenRepository
+	revocations   domain.RevocationRepository
+	providers     *ProviderRegistry
+	signer        *token.Signer
+	settings      config.TokenSettings
+	// clients holds the introspection client credentials.
+	clients map[string]string
+}
+
+func NewTokenService(users domain.UserRepository, refreshTokens domain.RefreshTokenRepository, revocations domain.RevocationRepository, providers *ProviderRegistry, signer *token.Signer, introspection config.IntrospectionConfig) *TokenService {
+	return &TokenService{
+		users:         users,
+		refreshTokens: refreshTokens,
+		revocations:   revocations,
+		providers:     providers,
+		signer:        signer,
+		settings:      signer.Settings(),
+		clients:       introspection.Clients,
+	}
+}
+
+// Login authenticates creds with the application's identity provider and
+// returns tokens in the Auth0 response shape existing clients expect.
+func (s *TokenService) Login(ctx context.Context, creds Credentials) (*Auth0TokenResponse, error) {
+	identity, err := s.providers.For(creds.Application).Authenticate(ctx, creds)
+	if err != nil {
+		return nil, err
+	}
+	activate(ctx, s.users, identity.User)
+	if identity.Token != nil && s.settings.Auth0Passthrough {
+		return identity.Token, nil
+	}
+
+	family, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTokens(ctx, identity.User, family)
+}
+
+// Refresh exchanges a refresh token for a new token set. The presented token
+// is consumed; presenting it again revokes its whole family, because only a
+// stolen copy would be replayed after the legitimate client has rotated.
+func (s *TokenService) Refresh(ctx context.Context, raw string) (*Auth0TokenResponse, error) {
+	stored, err := s.refreshTokens.FindByHash(ctx, hashToken(raw))
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stored.Revoked {
+		return nil, ErrInvalidRefreshToken
+	}
+	if stored.UsedAt != nil {
+		return nil, s.revokeReusedFamily(ctx, stored)
+	}
+	now := time.Now().UTC()
+	if now.After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	won, err := s.refreshTokens.MarkUsed(ctx, stored.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !won {
+		return nil, s.revokeReusedFamily(ctx, stored)
+	}
+
+	user, err := s.users.FindByID(ctx, stored.UserID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.Status.CanAuthenticate() {
+		if err := s.refreshTokens.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			log.Printf("revoking refresh token family %s of %s user %s: %v", stored.FamilyID, user.Status, user.ID.Hex(), err)
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return s.issueTokens(ctx, user, stored.FamilyID)
+}
+
+func (s *TokenService) revokeReusedFamily(ctx context.Context, t *domain.RefreshToken) error {
+	log.Printf("refresh token reuse for user %s, revoking family %s", t.UserID.Hex(), t.FamilyID)
+	if err := s.refreshTokens.RevokeFamily(ctx, t.FamilyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+func (s *TokenService) issueTokens(ctx context.Context, u *domain.User, family string) (*Auth0TokenResponse, error) {
+	access, exp, err := s.signer.IssueAccessToken(u)
+	if err != nil {
+		return nil, err
+	}
+	idToken, _, err := s.signer.IssueIDToken(u)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	err = s.refreshTokens.Create(ctx, &domain.RefreshToken{
+		Hash:        hashToken(refresh),
+		FamilyID:    family,
+		UserID:      u.ID,
+		Application: u.Application,
+		IssuedAt:    now,
+		ExpiresAt:   now.Add(s.settings.RefreshTokenTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Auth0TokenResponse{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    int(time.Until(exp).Seconds()),
+		TokenType:    "Bearer",
+		IDToken:      idToken,
+	}, nil
+}
+
+// activate moves an approved user to active on their first successful login.
+// A failure is logged rather than failing the login, which has already
+// succeeded.
+func activate(ctx context.Context, users domain.UserRepository, u *domain.User) {
+	if u.Status != domain.StatusApproved {
+		return
+	}
+	if err := u.Activate(u.ID.Hex()); err != nil {
+		fmt.Println("User activation failed:", err)
+		return
+	}
+	if err := users.UpdateStatus(ctx, u, domain.StatusApproved); err != nil && !errors.Is(err, domain.ErrInvalidTransition) {
+		fmt.Println("User activation failed:", err)
+	}
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is the lookup key for a stored opaque token. The tokens carry 256
+// bits of entropy, so a fast hash is enough.
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
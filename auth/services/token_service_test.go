@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+)
+
+func TestRefreshRotates(t *testing.T) {
+	f := newTokenFixture(t)
+	ctx := context.Background()
+	first := f.login(t)
+
+	second, err := f.svc.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.RefreshToken == "" || second.RefreshToken == first.RefreshToken {
+		t.Fatalf("refresh token not rotated: %q", second.RefreshToken)
+	}
+	if second.AccessToken == "" || second.IDToken == "" {
+		t.Error("refresh did not issue access and ID tokens")
+	}
+
+	stored, err := f.refreshTokens.FindByHash(ctx, hashToken(second.RefreshToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	family := f.refreshTokens.family(stored.FamilyID)
+	if len(family) != 2 {
+		t.Fatalf("family has %d tokens, want 2", len(family))
+	}
+	if family[0].UsedAt == nil || family[1].UsedAt != nil {
+		t.Error("only the presented token should be marked used")
+	}
+
+	// The rotated token keeps working.
+	if _, err := f.svc.Refresh(ctx, second.RefreshToken); err != nil {
+		t.Errorf("refresh with rotated token: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	f := newTokenFixture(t)
+	ctx := context.Background()
+	first := f.login(t)
+	second, err := f.svc.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := f.login(t)
+
+	if _, err := f.svc.Refresh(ctx, first.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reuse err = %v, want ErrRefreshTokenReused", err)
+	}
+	if !errors.Is(ErrRefreshTokenReused, domain.ErrUnauthorized) {
+		t.Error("reuse is not reported as unauthorized")
+	}
+	// The legitimate client's current token dies with the family...
+	if _, err := f.svc.Refresh(ctx, second.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("descendant err = %v, want ErrInvalidRefreshToken", err)
+	}
+	// ...but other logins are untouched.
+	if _, err := f.svc.Refresh(ctx, other.RefreshToken); err != nil {
+		t.Errorf("other family: %v", err)
+	}
+}
+
+func TestRefreshRejects(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unknown token", func(t *testing.T) {
+		f := newTokenFixture(t)
+		if _, err := f.svc.Refresh(ctx, "not-a-token"); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("err = %v, want ErrInvalidRefreshToken", err)
+		}
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		f := newTokenFixture(t)
+		tokens := f.login(t)
+		f.refreshTokens.tokens[0].ExpiresAt = time.Now().Add(-time.Second)
+		if _, err := f.svc.Refresh(ctx, tokens.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("err = %v, want ErrInvalidRefreshToken", err)
+		}
+	})
+
+	t.Run("suspended user", func(t *testing.T) {
+		f := newTokenFixture(t)
+		tokens := f.login(t)
+		f.users.users[f.user.ID].Status = domain.StatusSuspended
+		if _, err := f.svc.Refresh(ctx, tokens.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("err = %v, want ErrInvalidRefreshToken", err)
+		}
+		if !f.refreshTokens.tokens[0].Revoked {
+			t.Error("family of a suspended user not revoked")
+		}
+	})
+
+	t.Run("deleted user", func(t *testing.T) {
+		f := newTokenFixture(t)
+		tokens := f.login(t)
+		f.users.users[f.user.ID].Audit.Deleted = true
+		if _, err := f.svc.Refresh(ctx, tokens.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("err = %v, want ErrInvalidRefreshToken", err)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package token
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// JWK is the public half of an RSA signing key (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWK describes pub, identified by kid, as a signing key for RS256.
+func PublicJWK(kid string, pub *rsa.PublicKey) JWK {
+	return JWK{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: "RS256",
+		Kid: kid,
+		N:   encodeModulus(pub.N),
+		E:   encodeExponent(pub.E),
+	}
+}
+
+// EncryptionJWK describes pub, identified by kid, as a key clients encrypt
+// to with RSA-OAEP-256.
+func EncryptionJWK(kid string, pub *rsa.PublicKey) JWK {
+	return JWK{
+		Kty: "RSA",
+		Use: "enc",
+		Alg: "RSA-OAEP-256",
+		Kid: kid,
+		N:   encodeModulus(pub.N),
+		E:   encodeExponent(pub.E),
+	}
+}
+
+// SigningKeys returns the JWKS publishing every key in the ring that can
+// still verify, so tokens signed before a rotation stay verifiable.
+func (s *Signer) SigningKeys() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, k := range s.keys.Usable() {
+		set.Keys = append(set.Keys, PublicJWK(k.ID, &k.Private.PublicKey))
+	}
+	return set
+}
+
+func encodeModulus(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+func encodeExponent(e int) string {
+	return base64.RawURLEncoding.EncodeToString(big.NewInt(int64(e)).Bytes())
+}
@@ -0,0 +1,90 @@
+package token
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rupesh-sengar/golang-collection/auth/config"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"github.com/rupesh-sengar/golang-collection/auth/keyring"
+	"github.com/rupesh-sengar/golang-collection/auth/middleware"
+)
+
+const DefaultScope = "openid profile email"
+
+// Signer issues tokens with the key ring's primary key and verifies them
+// with any key still in the ring.
+type Signer struct {
+	keys     *keyring.Ring
+	settings config.TokenSettings
+}
+
+func NewSigner(keys *keyring.Ring, settings config.TokenSettings) *Signer {
+	return &Signer{keys: keys, settings: settings}
+}
+
+// Settings returns the token settings the signer was built with.
+func (s *Signer) Settings() config.TokenSettings {
+	return s.settings
+}
+
+// IssueAccessToken signs an access token for u, audienced for the APIs named
+// in the configured audience.
+func (s *Signer) IssueAccessToken(u *domain.User) (string, time.Time, error) {
+	return s.issue(u, middleware.UseAccess, s.settings.Audience, s.settings.AccessTokenTTL)
+}
+
+// IssueIDToken signs an OpenID Connect ID token for u, audienced for the
+// user's application.
+func (s *Signer) IssueIDToken(u *domain.User) (string, time.Time, error) {
+	return s.issue(u, middleware.UseID, u.Application, s.settings.IDTokenTTL)
+}
+
+func (s *Signer) issue(u *domain.User, use, audience string, ttl time.Duration) (string, time.Time, error) {
+	jti, err := newID()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now().UTC()
+	exp := now.Add(ttl)
+	claims := middleware.Claims{
+		TokenUse:    use,
+		Scope:       DefaultScope,
+		Email:       u.Email,
+		Roles:       u.Roles,
+		Application: u.Application,
+		Status:      u.Status,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.settings.Issuer,
+			Subject:   u.ID.Hex(),
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(exp),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ID:        jti,
+		},
+	}
+	if use == middleware.UseID {
+		claims.Name = u.Name.First + " " + u.Name.Last
+	}
+
+	key := s.keys.Primary()
+	t := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	t.Header["kid"] = key.ID
+	signed, err := t.SignedString(key.Private)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, exp, nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
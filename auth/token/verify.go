@@ -0,0 +1,51 @@
+package token
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rupesh-sengar/golang-collection/auth/middleware"
+)
+
+// VerificationKey returns the public key for kid. An empty kid matches the
+// primary key, for tokens minted before key IDs were added.
+func (s *Signer) VerificationKey(kid string) (*rsa.PublicKey, error) {
+	if kid == "" {
+		return &s.keys.Primary().Private.PublicKey, nil
+	}
+	key, ok := s.keys.Lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return &key.Private.PublicKey, nil
+}
+
+// ParseAccessToken checks raw's signature, issuer, audience and lifetime
+// against the signer's keys and returns its claims. ID tokens and anything
+// else whose token_use is not access are rejected. It does not consult the
+// revocation list; callers must check the jti themselves.
+func (s *Signer) ParseAccessToken(raw string) (*middleware.Claims, error) {
+	var claims middleware.Claims
+	_, err := jwt.ParseWithClaims(raw, &claims,
+		func(t *jwt.Token) (any, error) {
+			kid, _ := t.Header["kid"].(string)
+			return s.VerificationKey(kid)
+		},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(s.settings.Issuer),
+		jwt.WithAudience(s.settings.Audience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenUse != middleware.UseAccess {
+		return nil, fmt.Errorf("token_use is %q, not an access token", claims.TokenUse)
+	}
+	if claims.ID == "" {
+		return nil, errors.New("token has no jti")
+	}
+	return &claims, nil
+}
@@ -0,0 +1,111 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rupesh-sengar/golang-collection/auth/config"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"github.com/rupesh-sengar/golang-collection/auth/keyring"
+	"github.com/rupesh-sengar/golang-collection/auth/middleware"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	testKeysOnce sync.Once
+	testKeys     [2]*rsa.PrivateKey
+)
+
+func testSigner(t *testing.T, key int, settings config.TokenSettings) *Signer {
+	t.Helper()
+	testKeysOnce.Do(func() {
+		for i := range testKeys {
+			k, err := rsa.GenerateKey(rand.Reader, 2048)
+			if err != nil {
+				panic(err)
+			}
+			testKeys[i] = k
+		}
+	})
+	return NewSigner(keyring.Single(testKeys[key]), settings)
+}
+
+var testSettings = config.TokenSettings{
+	Issuer:         "https://auth.example.com",
+	Audience:       "api",
+	AccessTokenTTL: time.Minute,
+	IDTokenTTL:     time.Minute,
+}
+
+func testUser(application string) *domain.User {
+	return &domain.User{
+		ID:          primitive.NewObjectID(),
+		Email:       "a@example.com",
+		Roles:       []domain.UserRole{domain.RoleMember},
+		Application: application,
+		Status:      domain.StatusActive,
+	}
+}
+
+func TestParseAccessToken(t *testing.T) {
+	s := testSigner(t, 0, testSettings)
+	u := testUser("shop")
+	raw, _, err := s.IssueAccessToken(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := s.ParseAccessToken(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != u.ID.Hex() || claims.TokenUse != middleware.UseAccess || claims.ID == "" {
+		t.Errorf("claims = %+v", claims)
+	}
+}
+
+func TestParseAccessTokenRejects(t *testing.T) {
+	s := testSigner(t, 0, testSettings)
+	issue := func(t *testing.T, signer *Signer, u *domain.User, id bool) string {
+		t.Helper()
+		mint := signer.IssueAccessToken
+		if id {
+			mint = signer.IssueIDToken
+		}
+		raw, _, err := mint(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	otherIssuer, otherAudience, expired := testSettings, testSettings, testSettings
+	otherIssuer.Issuer = "https://evil.example.com"
+	otherAudience.Audience = "other-api"
+	expired.AccessTokenTTL = -time.Minute
+
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		// An ID token whose audience happens to be the API's must still be
+		// refused: only token_use tells the two apart.
+		{"ID token", issue(t, s, testUser(testSettings.Audience), true), "not an access token"},
+		{"other issuer", issue(t, testSigner(t, 0, otherIssuer), testUser("shop"), false), "issuer"},
+		{"other audience", issue(t, testSigner(t, 0, otherAudience), testUser("shop"), false), "audience"},
+		{"expired", issue(t, testSigner(t, 0, expired), testUser("shop"), false), "expired"},
+		{"other key", issue(t, testSigner(t, 1, testSettings), testUser("shop"), false), "key id"},
+		{"garbage", "not.a.jwt", "malformed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.ParseAccessToken(tt.raw)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
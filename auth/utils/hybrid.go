@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/rupesh-sengar/golang-collection/auth/keyring"
+)
+
+// EncryptedBodyContentType marks a request body that is a HybridEnvelope.
+const EncryptedBodyContentType = "application/encrypted+json"
+
+// HybridEnvelope carries data of any size encrypted with a client-generated
+// AES-256-GCM key, which is itself wrapped with RSA-OAEP-SHA256 to a ring key.
+// Binary fields are standard base64.
+type HybridEnvelope struct {
+	// Version is 1, the only scheme so far.
+	Version int `json:"v"`
+	// Kid names the ring key EncryptedKey is wrapped to. Without it every
+	// usable key is tried.
+	Kid          string `json:"kid,omitempty"`
+	EncryptedKey string `json:"ek"`
+	// Nonce is the 12-byte GCM nonce.
+	Nonce string `json:"iv"`
+	// Ciphertext includes the GCM tag.
+	Ciphertext string `json:"ct"`
+}
+
+// OpenHybrid unwraps env's content key with keys and decrypts its payload.
+// aad must equal the associated data the client sealed with, which binds the
+// ciphertext to where it was sent.
+func OpenHybrid(keys *keyring.Ring, env *HybridEnvelope, aad []byte) ([]byte, error) {
+	if env.Version != 1 {
+		return nil, fmt.Errorf("unsupported envelope version %d", env.Version)
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(env.EncryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("ek: %w", err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(env.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("iv: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(env.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("ct: %w", err)
+	}
+
+	candidates := keys.Usable()
+	if env.Kid != "" {
+		key, ok := keys.Lookup(env.Kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", env.Kid)
+		}
+		candidates = []*keyring.Key{key}
+	}
+	var contentKey []byte
+	for _, key := range candidates {
+		contentKey, err = rsa.DecryptOAEP(sha256.New(), nil, key.Private, wrapped, nil)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(contentKey) != 32 {
+		return nil, errors.New("content key is not an AES-256 key")
+	}
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("iv must be %d bytes", gcm.NonceSize())
+	}
+	return gcm.Open(nil, nonce, ciphertext, aad)
+}
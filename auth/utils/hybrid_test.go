@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+)
+
+// sealHybrid builds the envelope a client would send, wrapping contentKey to
+// pub.
+func sealHybrid(t *testing.T, pub *rsa.PublicKey, kid string, contentKey, plaintext, aad []byte) *HybridEnvelope {
+	t.Helper()
+	wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, contentKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, 12)
+	if _, err := rand.Read(nonce); err != nil {
+		t.Fatal(err)
+	}
+	var ciphertext []byte
+	if block, err := aes.NewCipher(contentKey); err == nil {
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertext = gcm.Seal(nil, nonce, plaintext, aad)
+	}
+	enc := base64.StdEncoding.EncodeToString
+	return &HybridEnvelope{
+		Version:      1,
+		Kid:          kid,
+		EncryptedKey: enc(wrapped),
+		Nonce:        enc(nonce),
+		Ciphertext:   enc(ciphertext),
+	}
+}
+
+func newContentKey(t *testing.T, size int) []byte {
+	t.Helper()
+	key := make([]byte, size)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestOpenHybrid(t *testing.T) {
+	ring := testRing(t)
+	body := []byte(`{"email":"a@example.com","password":"s3cret"}`)
+	aad := []byte("POST /api/v1/login")
+
+	for _, tt := range []struct {
+		name string
+		kid  string
+		key  *rsa.PrivateKey
+	}{
+		{"primary with kid", "new", testKeys[0]},
+		{"primary without kid", "", testKeys[0]},
+		{"retired key without kid", "", testKeys[1]},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			env := sealHybrid(t, &tt.key.PublicKey, tt.kid, newContentKey(t, 32), body, aad)
+			got, err := OpenHybrid(ring, env, aad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(body) {
+				t.Errorf("plaintext = %s", got)
+			}
+		})
+	}
+}
+
+func TestOpenHybridRejects(t *testing.T) {
+	ring := testRing(t)
+	body := []byte(`{"password":"s3cret"}`)
+	aad := []byte("POST /api/v1/login")
+	valid := func() *HybridEnvelope {
+		return sealHybrid(t, &testKeys[0].PublicKey, "new", newContentKey(t, 32), body, aad)
+	}
+
+	tests := []struct {
+		name string
+		env  func() *HybridEnvelope
+		aad  []byte
+	}{
+		{"other associated data", valid, []byte("POST /api/v1/signup")},
+		{"unknown version", func() *HybridEnvelope { e := valid(); e.Version = 2; return e }, aad},
+		{"unknown kid", func() *HybridEnvelope { e := valid(); e.Kid = "missing"; return e }, aad},
+		{"wrong kid", func() *HybridEnvelope { e := valid(); e.Kid = "old"; return e }, aad},
+		{"bad ek base64", func() *HybridEnvelope { e := valid(); e.EncryptedKey = "!"; return e }, aad},
+		{"bad iv base64", func() *HybridEnvelope { e := valid(); e.Nonce = "!"; return e }, aad},
+		{"bad ct base64", func() *HybridEnvelope { e := valid(); e.Ciphertext = "!"; return e }, aad},
+		{"short iv", func() *HybridEnvelope {
+			e := valid()
+			e.Nonce = base64.StdEncoding.EncodeToString(make([]byte, 8))
+			return e
+		}, aad},
+		{"tampered ciphertext", func() *HybridEnvelope {
+			e := valid()
+			ct, _ := base64.StdEncoding.DecodeString(e.Ciphertext)
+			ct[0] ^= 1
+			e.Ciphertext = base64.StdEncoding.EncodeToString(ct)
+			return e
+		}, aad},
+		{"aes-128 content key", func() *HybridEnvelope {
+			return sealHybrid(t, &testKeys[0].PublicKey, "new", newContentKey(t, 16), body, aad)
+		}, aad},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := OpenHybrid(ring, tt.env(), tt.aad); err == nil {
+				t.Errorf("OpenHybrid succeeded with %s", got)
+			}
+		})
+	}
+}
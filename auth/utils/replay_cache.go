@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// ReplayCache remembers digests of recently accepted ciphertexts until they
+// would have expired anyway, so each can be used only once. It is held in
+// memory, so each replica of the service keeps its own.
+type ReplayCache struct {
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewReplayCache() *ReplayCache {
+	return &ReplayCache{seen: map[string]time.Time{}, now: time.Now}
+}
+
+// Claim records digest as used until expires and reports whether it was
+// unused before.
+func (c *ReplayCache) Claim(digest string, expires time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if now.Sub(c.lastSweep) > time.Minute {
+		for d, exp := range c.seen {
+			if now.After(exp) {
+				delete(c.seen, d)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	if exp, ok := c.seen[digest]; ok && !now.After(exp) {
+		return false
+	}
+	c.seen[digest] = expires
+	return true
+}
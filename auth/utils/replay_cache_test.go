@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReplayCacheClaim(t *testing.T) {
+	now := time.Now()
+	c := NewReplayCache()
+	c.now = func() time.Time { return now }
+
+	if !c.Claim("a", now.Add(time.Minute)) {
+		t.Fatal("first claim refused")
+	}
+	if c.Claim("a", now.Add(time.Minute)) {
+		t.Error("second claim accepted")
+	}
+	if !c.Claim("b", now.Add(time.Minute)) {
+		t.Error("claim of another digest refused")
+	}
+
+	// Once the entry has expired the envelope is stale anyway, so the
+	// digest may be claimed again and old entries are swept.
+	now = now.Add(2 * time.Minute)
+	if !c.Claim("a", now.Add(time.Minute)) {
+		t.Error("claim after expiry refused")
+	}
+	if _, ok := c.seen["b"]; ok {
+		t.Error("expired entry not swept")
+	}
+}
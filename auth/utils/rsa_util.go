@@ -1,26 +1,127 @@
 package utils
 
 import (
+	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
-	"github.com/rupesh-sengar/golang-collection/auth/config"
+	"fmt"
+	"github.com/rupesh-sengar/golang-collection/auth/domain"
+	"github.com/rupesh-sengar/golang-collection/auth/keyring"
+	"strconv"
 	"strings"
+	"time"
 )
 
-func DecryptEncPassword(enc string) (string, error) {
+// enc_password envelope versions. The version is the envelope's second field
+// and selects the RSA padding the client used.
+const (
+	// EnvelopePKCS1v15 is the original scheme, kept for clients that have
+	// not migrated. PKCS#1 v1.5 decryption is open to padding-oracle
+	// attacks, so deployments should retire it with a minimum version.
+	EnvelopePKCS1v15 = 0
+	// EnvelopeOAEPSHA256 uses RSA-OAEP with SHA-256 and an empty label.
+	EnvelopeOAEPSHA256 = 1
+
+	LatestEnvelopeVersion = EnvelopeOAEPSHA256
+)
+
+var (
+	ErrPasswordExpired  = fmt.Errorf("enc_password timestamp is outside the accepted window: %w", domain.ErrUnauthorized)
+	ErrPasswordReplayed = fmt.Errorf("enc_password has already been used: %w", domain.ErrUnauthorized)
+)
+
+// PasswordDecrypter opens the enc_password envelopes clients send.
+type PasswordDecrypter struct {
+	keys       *keyring.Ring
+	minVersion int
+	window     time.Duration
+	replays    *ReplayCache
+	now        func() time.Time
+}
+
+// NewPasswordDecrypter decrypts with keys, rejects envelope versions below
+// minVersion, and accepts each envelope once and only while its timestamp
+// is within window of the server clock.
+func NewPasswordDecrypter(keys *keyring.Ring, minVersion int, window time.Duration) *PasswordDecrypter {
+	return &PasswordDecrypter{
+		keys:       keys,
+		minVersion: minVersion,
+		window:     window,
+		replays:    NewReplayCache(),
+		now:        time.Now,
+	}
+}
+
+// Keys returns the ring envelopes are decrypted with.
+func (d *PasswordDecrypter) Keys() *keyring.Ring {
+	return d.keys
+}
+
+// SupportedVersions lists the envelope versions Decrypt accepts, oldest first.
+func (d *PasswordDecrypter) SupportedVersions() []int {
+	var versions []int
+	for v := d.minVersion; v <= LatestEnvelopeVersion; v++ {
+		versions = append(versions, v)
+	}
+	return versions
+}
+
+// Decrypt opens "<tag>:<version>:<timestamp>:<ciphertext>[:<kid>]". The
+// optional kid picks the ring key the client encrypted to; envelopes from
+// clients that predate key IDs are tried against every usable key, primary
+// first.
+//
+// The plaintext is "<timestamp>:<password>". Its timestamp must equal the
+// envelope's, which binds the otherwise unauthenticated outer field to the
+// ciphertext, and must be within the window. Unix seconds and milliseconds
+// are both accepted.
+func (d *PasswordDecrypter) Decrypt(enc string) (string, error) {
 	parts := strings.Split(enc, ":")
-	if len(parts) < 4 {
+	if len(parts) < 4 || len(parts) > 5 {
 		return "", errors.New("invalid enc_password format")
 	}
 
+	version, err := strconv.Atoi(parts[1])
+	if err != nil || version < EnvelopePKCS1v15 || version > LatestEnvelopeVersion {
+		return "", fmt.Errorf("unsupported enc_password version %q", parts[1])
+	}
+	if version < d.minVersion {
+		return "", fmt.Errorf("enc_password version %d is no longer accepted, use %d or later", version, d.minVersion)
+	}
+
+	sentAt, err := parseTimestamp(parts[2])
+	if err != nil {
+		return "", err
+	}
+	if skew := d.now().Sub(sentAt); skew > d.window || skew < -d.window {
+		return "", ErrPasswordExpired
+	}
+
 	encryptedBase64 := parts[3]
 	encryptedBytes, err := base64.StdEncoding.DecodeString(encryptedBase64)
 	if err != nil {
 		return "", err
 	}
 
-	decryptedBytes, err := rsa.DecryptPKCS1v15(nil, config.PrivateKey, encryptedBytes)
+	candidates := d.keys.Usable()
+	if len(parts) == 5 {
+		key, ok := d.keys.Lookup(parts[4])
+		if !ok {
+			return "", fmt.Errorf("unknown key id %q", parts[4])
+		}
+		candidates = []*keyring.Key{key}
+	}
+
+	var decryptedBytes []byte
+	for _, key := range candidates {
+		decryptedBytes, err = decrypt(version, key.Private, encryptedBytes)
+		if err == nil {
+			break
+		}
+	}
 	if err != nil {
 		return "", err
 	}
@@ -30,6 +131,62 @@ func DecryptEncPassword(enc string) (string, error) {
 	if len(split) != 2 {
 		return "", errors.New("decrypted payload malformed")
 	}
+	if split[0] != parts[2] {
+		return "", errors.New("enc_password timestamp does not match its payload")
+	}
+
+	digest := sha256.Sum256(encryptedBytes)
+	if !d.replays.Claim(hex.EncodeToString(digest[:]), sentAt.Add(d.window)) {
+		return "", ErrPasswordReplayed
+	}
 
 	return split[1], nil
 }
+
+// EncryptPassword builds the enc_password envelope a client would send for
+// password, encrypted to pub with the given envelope version. kid is
+// appended when set. It exists for tooling and tests; browsers build the
+// envelope themselves.
+func EncryptPassword(version int, kid string, pub *rsa.PublicKey, password string, now time.Time) (string, error) {
+	ts := strconv.FormatInt(now.Unix(), 10)
+	plaintext := []byte(ts + ":" + password)
+
+	var ciphertext []byte
+	var err error
+	switch version {
+	case EnvelopeOAEPSHA256:
+		ciphertext, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, plaintext, nil)
+	case EnvelopePKCS1v15:
+		ciphertext, err = rsa.EncryptPKCS1v15(rand.Reader, pub, plaintext)
+	default:
+		return "", fmt.Errorf("unsupported enc_password version %d", version)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	enc := "#PWD:" + strconv.Itoa(version) + ":" + ts + ":" + base64.StdEncoding.EncodeToString(ciphertext)
+	if kid != "" {
+		enc += ":" + kid
+	}
+	return enc, nil
+}
+
+func parseTimestamp(raw string) (time.Time, error) {
+	ts, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid enc_password timestamp %q", raw)
+	}
+	// Seconds will not reach 1e11 until the year 5138.
+	if ts >= 1e11 {
+		return time.UnixMilli(ts), nil
+	}
+	return time.Unix(ts, 0), nil
+}
+
+func decrypt(version int, key *rsa.PrivateKey, ciphertext []byte) ([]byte, error) {
+	if version == EnvelopeOAEPSHA256 {
+		return rsa.DecryptOAEP(sha256.New(), nil, key, ciphertext, nil)
+	}
+	return rsa.DecryptPKCS1v15(nil, key, ciphertext)
+}
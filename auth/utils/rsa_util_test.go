@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rupesh-sengar/golang-collection/auth/keyring"
+)
+
+var (
+	testKeysOnce sync.Once
+	testKeys     [2]*rsa.PrivateKey
+)
+
+// testRing returns a ring whose primary is "new" and which still holds "old",
+// retired an hour ago with a day's grace. The keys are generated once, since
+// RSA key generation dominates the tests' run time otherwise.
+func testRing(t *testing.T) *keyring.Ring {
+	t.Helper()
+	testKeysOnce.Do(func() {
+		for i := range testKeys {
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			if err != nil {
+				panic(err)
+			}
+			testKeys[i] = key
+		}
+	})
+	retired := time.Now().Add(-time.Hour)
+	ring, err := keyring.New([]*keyring.Key{
+		{ID: "new", Private: testKeys[0]},
+		{ID: "old", Private: testKeys[1], RetiredAt: &retired},
+	}, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ring
+}
+
+func mustEncrypt(t *testing.T, version int, kid string, key *rsa.PrivateKey, password string, now time.Time) string {
+	t.Helper()
+	enc, err := EncryptPassword(version, kid, &key.PublicKey, password, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enc
+}
+
+func TestDecryptRoundTrip(t *testing.T) {
+	ring := testRing(t)
+	tests := []struct {
+		name    string
+		version int
+		kid     string
+		key     *rsa.PrivateKey
+	}{
+		{"oaep with kid", EnvelopeOAEPSHA256, "new", testKeys[0]},
+		{"oaep without kid", EnvelopeOAEPSHA256, "", testKeys[0]},
+		{"retired key with kid", EnvelopeOAEPSHA256, "old", testKeys[1]},
+		{"retired key without kid", EnvelopeOAEPSHA256, "", testKeys[1]},
+		{"pkcs1v15 when enabled", EnvelopePKCS1v15, "new", testKeys[0]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewPasswordDecrypter(ring, EnvelopePKCS1v15, time.Minute)
+			got, err := d.Decrypt(mustEncrypt(t, tt.version, tt.kid, tt.key, "s3cret:with:colons", time.Now()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != "s3cret:with:colons" {
+				t.Errorf("password = %q", got)
+			}
+		})
+	}
+}
+
+func TestDecryptRejectsVersionBelowMinimum(t *testing.T) {
+	d := NewPasswordDecrypter(testRing(t), EnvelopeOAEPSHA256, time.Minute)
+	_, err := d.Decrypt(mustEncrypt(t, EnvelopePKCS1v15, "new", testKeys[0], "password", time.Now()))
+	if err == nil || !strings.Contains(err.Error(), "no longer accepted") {
+		t.Errorf("err = %v, want version no longer accepted", err)
+	}
+	if got := d.SupportedVersions(); len(got) != 1 || got[0] != EnvelopeOAEPSHA256 {
+		t.Errorf("SupportedVersions() = %v, want [1]", got)
+	}
+}
+
+func TestDecryptRejectsMalformedEnvelopes(t *testing.T) {
+	d := NewPasswordDecrypter(testRing(t), EnvelopeOAEPSHA256, time.Minute)
+	valid := mustEncrypt(t, EnvelopeOAEPSHA256, "new", testKeys[0], "password", time.Now())
+	parts := strings.Split(valid, ":")
+	replace := func(i int, v string) string {
+		p := append([]string(nil), parts...)
+		p[i] = v
+		return strings.Join(p, ":")
+	}
+
+	tests := []struct {
+		name string
+		enc  string
+	}{
+		{"too few fields", strings.Join(parts[:3], ":")},
+		{"too many fields", valid + ":extra"},
+		{"non-numeric version", replace(1, "one")},
+		{"unknown version", replace(1, "2")},
+		{"non-numeric timestamp", replace(2, "yesterday")},
+		{"bad base64", replace(3, "not base64!")},
+		{"unknown kid", replace(4, "missing")},
+		{"wrong kid", replace(4, "old")},
+		{"corrupt ciphertext", replace(3, "AAAA"+parts[3][4:])},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := d.Decrypt(tt.enc); err == nil {
+				t.Errorf("Decrypt succeeded with %q", got)
+			}
+		})
+	}
+}
+
+func TestEncryptPasswordRejectsUnknownVersion(t *testing.T) {
+	testRing(t)
+	if _, err := EncryptPassword(7, "", &testKeys[0].PublicKey, "password", time.Now()); err == nil {
+		t.Error("EncryptPassword accepted version 7")
+	}
+}
+
+func TestDecryptTimestampWindow(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		sentAt time.Time
+		ok     bool
+	}{
+		{"now", now, true},
+		{"slightly old", now.Add(-50 * time.Second), true},
+		{"slightly ahead", now.Add(50 * time.Second), true},
+		{"too old", now.Add(-2 * time.Minute), false},
+		{"too far ahead", now.Add(2 * time.Minute), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewPasswordDecrypter(testRing(t), EnvelopeOAEPSHA256, time.Minute)
+			d.now = func() time.Time { return now }
+			_, err := d.Decrypt(mustEncrypt(t, EnvelopeOAEPSHA256, "new", testKeys[0], "password", tt.sentAt))
+			if tt.ok && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrPasswordExpired) {
+				t.Errorf("err = %v, want ErrPasswordExpired", err)
+			}
+		})
+	}
+}
+
+func TestDecryptAcceptsMillisecondTimestamps(t *testing.T) {
+	now := time.Now()
+	ts := strconv.FormatInt(now.UnixMilli(), 10)
+	ciphertext, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, &testRing(t).Primary().Private.PublicKey, []byte(ts+":password"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewPasswordDecrypter(testRing(t), EnvelopeOAEPSHA256, time.Minute)
+	got, err := d.Decrypt("#PWD:1:" + ts + ":" + base64.StdEncoding.EncodeToString(ciphertext))
+	if err != nil || got != "password" {
+		t.Errorf("Decrypt = %q, %v", got, err)
+	}
+}
+
+// The outer timestamp is not covered by the RSA padding, so it must match
+// the one inside the ciphertext; otherwise an old capture could be replayed
+// with a fresh outer timestamp.
+func TestDecryptRejectsTimestampMismatch(t *testing.T) {
+	d := NewPasswordDecrypter(testRing(t), EnvelopeOAEPSHA256, time.Minute)
+	old := mustEncrypt(t, EnvelopeOAEPSHA256, "new", testKeys[0], "password", time.Now().Add(-time.Hour))
+	parts := strings.Split(old, ":")
+	parts[2] = strconv.FormatInt(time.Now().Unix(), 10)
+	if _, err := d.Decrypt(strings.Join(parts, ":")); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("err = %v, want timestamp mismatch", err)
+	}
+}
+
+func TestDecryptRejectsReplay(t *testing.T) {
+	d := NewPasswordDecrypter(testRing(t), EnvelopeOAEPSHA256, time.Minute)
+	enc := mustEncrypt(t, EnvelopeOAEPSHA256, "new", testKeys[0], "password", time.Now())
+	if _, err := d.Decrypt(enc); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Decrypt(enc); !errors.Is(err, ErrPasswordReplayed) {
+		t.Errorf("second Decrypt err = %v, want ErrPasswordReplayed", err)
+	}
+	// Replays are told apart by ciphertext, so the kid can't be dropped to
+	// slip past the cache.
+	withoutKid := enc[:strings.LastIndex(enc, ":")]
+	if _, err := d.Decrypt(withoutKid); !errors.Is(err, ErrPasswordReplayed) {
+		t.Errorf("Decrypt without kid err = %v, want ErrPasswordReplayed", err)
+	}
+	// A fresh envelope for the same password is fine.
+	if _, err := d.Decrypt(mustEncrypt(t, EnvelopeOAEPSHA256, "new", testKeys[0], "password", time.Now())); err != nil {
+		t.Errorf("fresh envelope: %v", err)
+	}
+}
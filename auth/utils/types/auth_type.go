@@ -5,15 +5,13 @@ import (
 )
 
 type SignupRequest struct {
-	FirstName   string `json:"first_name"`
-	LastName    string `json:"last_name"`
-	Application string `json:"application"`
-	Email       string `json:"email"`
-	Password    string `json:"password"`
-	CreatorID   string `json:"creator_id"`
+	FirstName   string `json:"first_name" binding:"required"`
+	LastName    string `json:"last_name" binding:"required"`
+	Application string `json:"application" binding:"required"`
+	Email       string `json:"email" binding:"required,emailVO"`
+	Password    string `json:"password" binding:"required"`
 }
 
-type AuthApprovalRequest struct{
-	Id primitive.ObjectID `json:"id"`
-	ApproverID string `json:"approver_id"`
-}
\ No newline at end of file
+type AuthApprovalRequest struct {
+	Id primitive.ObjectID `json:"id" binding:"required"`
+}
@@ -1,7 +1,43 @@
 package main
-import ("fmt")
 
-func main(){
-	fmt.Println("This is a placeholder for the main function in the commitly server package.")
-	fmt.Println("You can implement your server logic here, such as initializing the server, connecting to databases, etc.")
-}
\ No newline at end of file
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rupesh-sengar/golang-collection/auth/middleware"
+)
+
+// commitly trusts access tokens from the auth service. Point it at the auth
+// service with AUTH_ISSUER and AUTH_JWKS_URL (and AUTH_AUDIENCE if tokens are
+// audienced).
+func main() {
+	cfg, err := middleware.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid auth configuration: %v", err)
+	}
+	middleware.Configure(cfg)
+
+	r := gin.Default()
+
+	api := r.Group("/api/v1", middleware.RequireAuth())
+	{
+		api.GET("/me", func(c *gin.Context) {
+			p, _ := middleware.GetPrincipal(c)
+			c.JSON(http.StatusOK, gin.H{
+				"user_id":     p.UserID,
+				"email":       p.Email,
+				"roles":       p.Roles,
+				"application": p.Application,
+			})
+		})
+	}
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8081"
+	}
+	log.Printf("commitly is running on port %s", port)
+	r.Run(":" + port)
+}
@@ -1,48 +0,0 @@
-package main
-
-import (
-	"log"
-	"os"
-	"time"
-
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
-	"github.com/rupesh-sengar/golang-collection/auth/config"
-	"github.com/rupesh-sengar/golang-collection/auth/database"
-	"github.com/rupesh-sengar/golang-collection/auth/routes"
-)
-
-func main() {
-	if err := godotenv.Overload(".env"); err != nil {
-		log.Println("No .env file found. Using system environment variables.")
-	}
-
-	if err := database.ConnectDB(); err != nil {
-		log.Fatalf("Failed to connect to MongoDB: %v", err)
-	}
-
-	err := config.LoadRSAKeys()
-	if err != nil {
-		log.Fatalf("Failed to load RSA keys: %v", err)
-	}
-
-	r := gin.Default()
-	config := cors.Config{
-		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}
-	r.Use(cors.New(config))
-	routes.RegisterRoutes(r)
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-	log.Printf("Server is running on port %s", port)
-	r.Run(":" + port)
-}